@@ -0,0 +1,248 @@
+package track
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+/* Binary track format:
+
+The data starts with BinaryHeader, followed by point records.
+Each record has a flags byte, and the differences of the
+time (milliseconds) and the coordinates (degrees multiplied by 1e7)
+from the previous point as signed varints.
+
+If the flags has binaryAcc set, the accuracy in decimeters
+follows as an unsigned varint.
+
+If the flags has binaryEle set, the difference of the elevation
+in decimeters from the previous point having an elevation
+follows as a signed varint.
+
+*/
+
+// BinaryHeader is the header of binary track data,
+// as written by Writer and Track.MarshalBinary.
+const BinaryHeader = "trak\x01"
+
+// ErrBinary indicates invalid binary track data.
+var ErrBinary = errors.New("track: invalid binary data")
+
+// binary point record flags
+const (
+	binaryAcc = 1 << iota
+	binaryEle
+
+	binaryFlags = binaryAcc | binaryEle
+)
+
+// MarshalBinary encodes trk in the binary track format.
+func (trk Track) MarshalBinary() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	w := NewWriter(buf)
+	for _, p := range trk {
+		if err := w.WritePoint(p); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary decodes *ptrk from data in the binary track format,
+// replacing the points of *ptrk.
+func (ptrk *Track) UnmarshalBinary(data []byte) error {
+	trk := (*ptrk)[:0]
+	r := NewReader(bytes.NewReader(data))
+	for {
+		p, err := r.ReadPoint()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		trk = append(trk, p)
+	}
+	*ptrk = trk
+	return nil
+}
+
+// Writer writes track points in the binary track format.
+//
+// The points written should be in chronological order
+// for compact output, but this is not required.
+type Writer struct {
+	w   *bufio.Writer
+	err error
+
+	started bool
+	last    Point
+	lastEle int32 // elevation of the last point having one
+
+	buf []byte
+}
+
+// NewWriter returns a new Writer writing to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{
+		w:   bufio.NewWriter(w),
+		buf: make([]byte, 1+5*binary.MaxVarintLen64),
+	}
+}
+
+func (w *Writer) start() {
+	if !w.started && w.err == nil {
+		w.started = true
+		_, w.err = w.w.WriteString(BinaryHeader)
+	}
+}
+
+// WritePoint writes p to the underlying writer.
+func (w *Writer) WritePoint(p Point) error {
+	w.start()
+	if w.err != nil {
+		return w.err
+	}
+
+	var flags byte
+	if p.acc != 0 {
+		flags |= binaryAcc
+	}
+	if p.hasEle {
+		flags |= binaryEle
+	}
+
+	b := w.buf
+	b[0] = flags
+	n := 1
+	n += binary.PutVarint(b[n:], p.t-w.last.t)
+	n += binary.PutVarint(b[n:], int64(p.lat)-int64(w.last.lat))
+	n += binary.PutVarint(b[n:], int64(p.long)-int64(w.last.long))
+	if p.acc != 0 {
+		n += binary.PutUvarint(b[n:], uint64(p.acc-1))
+	}
+	if p.hasEle {
+		n += binary.PutVarint(b[n:], int64(p.ele)-int64(w.lastEle))
+		w.lastEle = p.ele
+	}
+
+	w.last = p
+	_, w.err = w.w.Write(b[:n])
+	return w.err
+}
+
+// Flush writes any buffered data to the underlying writer.
+//
+// The header is written by Flush if no points were written,
+// so that the output holds a valid empty track.
+func (w *Writer) Flush() error {
+	w.start()
+	if w.err != nil {
+		return w.err
+	}
+	w.err = w.w.Flush()
+	return w.err
+}
+
+// Reader reads track points in the binary track format.
+type Reader struct {
+	r   *bufio.Reader
+	err error // sticky error
+
+	started bool
+	last    Point
+	lastEle int32 // elevation of the last point having one
+}
+
+// NewReader returns a new Reader reading from r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// ReadPoint reads the next track point.
+//
+// It returns io.EOF at the end of input, and ErrBinary
+// if the input is not in the binary track format.
+//
+// The binary format can't be resynchronized after an error,
+// therefore once ReadPoint returned an error,
+// it returns the same error on subsequent calls.
+func (r *Reader) ReadPoint() (Point, error) {
+	if r.err != nil {
+		return Point{}, r.err
+	}
+	p, err := r.readPoint()
+	if err != nil {
+		r.err = err
+	}
+	return p, err
+}
+
+func (r *Reader) readPoint() (Point, error) {
+	if !r.started {
+		hdr := make([]byte, len(BinaryHeader))
+		if _, err := io.ReadFull(r.r, hdr); err != nil || string(hdr) != BinaryHeader {
+			return Point{}, ErrBinary
+		}
+		r.started = true
+	}
+
+	flags, err := r.r.ReadByte()
+	if err != nil {
+		return Point{}, err
+	}
+	if flags&^binaryFlags != 0 {
+		return Point{}, ErrBinary
+	}
+
+	var d [3]int64
+	for i := range d {
+		if d[i], err = binary.ReadVarint(r.r); err != nil {
+			return Point{}, unexpectedEOF(err)
+		}
+	}
+
+	p := Point{
+		t:    r.last.t + d[0],
+		lat:  int32(int64(r.last.lat) + d[1]),
+		long: int32(int64(r.last.long) + d[2]),
+	}
+
+	if flags&binaryAcc != 0 {
+		acc, err := binary.ReadUvarint(r.r)
+		if err != nil {
+			return Point{}, unexpectedEOF(err)
+		}
+		if acc >= 0xffff {
+			return Point{}, ErrBinary
+		}
+		p.acc = uint16(acc) + 1
+	}
+
+	if flags&binaryEle != 0 {
+		de, err := binary.ReadVarint(r.r)
+		if err != nil {
+			return Point{}, unexpectedEOF(err)
+		}
+		p.hasEle, p.ele = true, int32(int64(r.lastEle)+de)
+		r.lastEle = p.ele
+	}
+
+	r.last = p
+	return p, nil
+}
+
+// unexpectedEOF returns io.ErrUnexpectedEOF if err is io.EOF,
+// otherwise it returns err.
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
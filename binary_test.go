@@ -0,0 +1,72 @@
+package track_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/tajtiattila/track"
+)
+
+func TestBinary(t *testing.T) {
+	epoch := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	gen := timeTrkGen(epoch, 46, 17)
+
+	src := gen.trk(1000, 0, 1.0)
+	for i := range src {
+		if i%3 == 0 {
+			src[i] = src[i].WithAcc(float64(i%50) + 0.5)
+		}
+		if i%2 == 0 {
+			src[i] = src[i].WithEle(100 - float64(i)/10)
+		}
+	}
+	// points need not be in chronological order
+	src = append(src, gen.trk(10, -100, 1.0)...)
+
+	data, err := src.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(data) / len(src); n > 8 {
+		t.Errorf("got %d bytes per point", n)
+	}
+
+	var got track.Track
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(src) {
+		t.Fatalf("got %d points, want %d", len(got), len(src))
+	}
+	for i := range src {
+		if got[i] != src[i] {
+			t.Fatalf("point %d: got %+v, want %+v", i, got[i], src[i])
+		}
+	}
+
+	if err := got.UnmarshalBinary(data[:len(data)-1]); err != io.ErrUnexpectedEOF {
+		t.Fatalf("got error %v for truncated data, want io.ErrUnexpectedEOF", err)
+	}
+	if err := got.UnmarshalBinary([]byte("not a track")); err != track.ErrBinary {
+		t.Fatalf("got error %v for invalid data, want ErrBinary", err)
+	}
+
+	// errors are sticky
+	r := track.NewReader(bytes.NewReader(data[:len(track.BinaryHeader)+2]))
+	for i := 0; i < 2; i++ {
+		if _, err := r.ReadPoint(); err != io.ErrUnexpectedEOF {
+			t.Fatalf("read #%d: got error %v, want io.ErrUnexpectedEOF", i, err)
+		}
+	}
+
+	// empty track
+	buf := new(bytes.Buffer)
+	if err := track.NewWriter(buf).Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := track.NewReader(buf).ReadPoint(); err != io.EOF {
+		t.Fatalf("got error %v for empty track, want io.EOF", err)
+	}
+}
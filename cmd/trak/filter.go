@@ -1,11 +1,9 @@
 package main
 
 import (
-	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
-	"math"
 	"os"
 	"time"
 
@@ -19,8 +17,12 @@ type FilterCmd struct {
 
 	date, span string
 
-	json bool
-	gpx  bool
+	json    bool
+	gpx     bool
+	kml     bool
+	geojson bool
+	csv     bool
+	trak    bool
 }
 
 func init() {
@@ -31,7 +33,11 @@ func init() {
 		flags.StringVar(&c.date, "date", "", "filter on date (RFC3339)")
 		flags.StringVar(&c.span, "span", "", "date filter span, one of year, month, day, hour (default inferred from -date)")
 		flags.BoolVar(&c.json, "json", false, "print json output similar to google location history json")
-		flags.BoolVar(&c.gpx, "gpx", false, "print gpx output (with no accuracy info)")
+		flags.BoolVar(&c.gpx, "gpx", false, "print gpx output")
+		flags.BoolVar(&c.kml, "kml", false, "print kml output (with no accuracy info)")
+		flags.BoolVar(&c.geojson, "geojson", false, "print geojson output")
+		flags.BoolVar(&c.csv, "csv", false, "print csv output")
+		flags.BoolVar(&c.trak, "trak", false, "print compact binary output to be used as a cache")
 		return c
 	})
 }
@@ -99,7 +105,7 @@ func (c *FilterCmd) Run(args []string) error {
 }
 
 func (c *FilterCmd) filter(fn string, start, end time.Time) error {
-	trk, err := load(fn)
+	trk, d, err := loadDecoder(fn, start, end)
 	if err != nil {
 		return err
 	}
@@ -114,15 +120,28 @@ func (c *FilterCmd) filter(fn string, start, end time.Time) error {
 	}
 	trk = trk[si:ei]
 
-	if c.json {
-		err = writeJSON(os.Stdout, trk)
-	} else if c.gpx {
-		err = writeGPX(os.Stdout, trk)
-	} else {
-		err = dumpTrack(os.Stdout, trk)
-	}
-
-	return err
+	var format string
+	switch {
+	case c.json:
+		format = "googlejson"
+	case c.gpx:
+		format = "gpx"
+	case c.kml:
+		format = "kml"
+	case c.geojson:
+		format = "geojson"
+	case c.csv:
+		format = "csv"
+	case c.trak:
+		format = "trak"
+	default:
+		return dumpTrack(os.Stdout, trk)
+	}
+
+	e := trackio.NewEncoder(os.Stdout, format)
+	e.SetMetadata(d.Metadata())
+	e.SetWaypoints(d.Waypoints(), d.Routes())
+	return e.Encode(trk)
 }
 
 func dumpTrack(w io.Writer, trk trackio.Track) error {
@@ -141,61 +160,6 @@ func dumpTrack(w io.Writer, trk trackio.Track) error {
 	return xw.Err()
 }
 
-func writeGPX(w io.Writer, trk trackio.Track) error {
-	xw := newErrWriter(w)
-	xw.WriteString(`<?xml version="1.0"?>
-<gpx xmlns="http://www.topografix.com/GPX/1/1">
-  <trk>
-    <trkseg>
-`)
-	for _, p := range trk {
-		fmt.Fprintf(xw, `      <trkpt lat="%.6f" lon="%.6f">`, p.Lat, p.Long)
-		fmt.Fprintf(xw, " <time>%s</time> </trkpt>\n", p.Time.UTC().Format(time.RFC3339))
-	}
-
-	xw.WriteString("    </trkseg>\n  </trk>\n</gpx>\n")
-	return xw.Err()
-}
-
-func writeJSON(w io.Writer, trk trackio.Track) error {
-	xw := newErrWriter(w)
-	xw.WriteString(`{"locations" : [ `)
-	for i, p := range trk {
-		if i != 0 {
-			xw.WriteString(", ")
-		}
-
-		var jp struct {
-			Ts     string   `json:"timestampMs"`
-			LatE7  float64  `json:"latitudeE7"`
-			LongE7 float64  `json:"longitudeE7"`
-			Acc    *float64 `json:"accuracy,omitempty"`
-			Ele    *float64 `json:"altitude,omitempty"`
-			VAcc   *float64 `json:"verticalAccuracy,omitempty"`
-		}
-		jp.Ts = fmt.Sprint(p.Time.UnixNano() / 1e6)
-		jp.LatE7 = math.Floor(p.Lat*1e7 + 0.5)
-		jp.LongE7 = math.Floor(p.Long*1e7 + 0.5)
-
-		if p.Acc < trackio.NoAccuracy {
-			jp.Acc = &p.Acc
-		}
-		if p.Ele.Valid {
-			jp.Ele = &p.Ele.Float64
-			if p.Ele.Acc < trackio.NoAccuracy {
-				jp.VAcc = &p.Ele.Acc
-			}
-		}
-		v, err := json.MarshalIndent(jp, " ", " ")
-		if err != nil {
-			panic(err)
-		}
-		xw.Write(v)
-	}
-	xw.WriteString(" ]\n}\n")
-	return xw.Err()
-}
-
 type errWriter struct {
 	w   io.Writer
 	err error
@@ -215,11 +179,3 @@ func (w *errWriter) Write(p []byte) (n int, err error) {
 	w.err = err
 	return n, err
 }
-
-func (w *errWriter) WriteString(s string) error {
-	if w.err != nil {
-		return w.err
-	}
-	_, err := w.Write([]byte(s))
-	return err
-}
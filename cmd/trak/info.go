@@ -3,8 +3,6 @@ package main
 import (
 	"flag"
 	"fmt"
-	"io"
-	"os"
 	"time"
 
 	"github.com/tajtiattila/cmdmain"
@@ -48,27 +46,24 @@ func (i *InfoCmd) Run(args []string) error {
 }
 
 func (i *InfoCmd) trackInfo(fn string) {
-	f, err := os.Open(fn)
+	d, closer, err := openDecoder(fn, time.Time{}, time.Time{})
 	check(err)
-	defer f.Close()
+	defer closer.Close()
 
-	d := trackio.NewDecoder(f)
 	if cli.inacc {
 		d.Accuracy = trackio.NoAccuracy
 	}
 
 	var trk track.Track
-	for {
-		pt, reset, err := d.Point()
-		if err == io.EOF {
-			break
-		}
-		check(err)
+	err = d.Points(cli.ctx, func(pt trackio.Point, reset bool) error {
 		if reset {
 			trk = trk[:0]
 		}
-		trk = append(trk, track.Pt(pt.Time, pt.Lat, pt.Long))
-	}
+		trk = append(trk, pt.Compact())
+		return nil
+	})
+	endProgress(d)
+	check(fileError(fn, err))
 	trk.Sort()
 
 	fmt.Printf("%s:\n %d points\n", fn, len(trk))
@@ -4,11 +4,14 @@ import (
 	"flag"
 	"fmt"
 	"math"
+	"time"
 
 	"github.com/tajtiattila/cmdmain"
 	"github.com/tajtiattila/geocode"
 	"github.com/tajtiattila/track"
 	"github.com/tajtiattila/track/geomath"
+	"github.com/tajtiattila/track/trackio"
+	"github.com/tajtiattila/track/trackstore"
 )
 
 type NearCmd struct {
@@ -42,33 +45,57 @@ func (c *NearCmd) Run(args []string) error {
 	}
 	defer gc.Close()
 
-	var trk track.Track
-	for _, fn := range args[1:] {
-		seg, err := load(fn)
+	q3, d2, err := c.area(gc, args[0])
+	if err != nil {
+		return err
+	}
+	f := &nearFinder{q3: q3, d2: d2}
+
+	paths := args[1:]
+	if len(paths) == 1 && trackstore.IsStore(paths[0]) {
+		// read store partitions one at a time
+		// instead of loading the whole history
+		d, closer, err := openDecoder(paths[0], time.Time{}, time.Time{})
 		if err != nil {
 			return err
 		}
-		trk.Merge(trackTrack(seg))
+		defer closer.Close()
+
+		err = d.Points(cli.ctx, func(pt trackio.Point, reset bool) error {
+			f.point(pt.Compact())
+			return nil
+		})
+		endProgress(d)
+		return fileError(paths[0], err)
 	}
 
-	return c.find(gc, args[0], trk)
+	trk, err := loadAll(paths, time.Time{}, time.Time{})
+	if err != nil {
+		return err
+	}
+	for _, p := range trk {
+		f.point(p)
+	}
+	return nil
 }
 
-func (c *NearCmd) find(gc geocode.Geocoder, place string, trk track.Track) error {
+// area returns the center of place, and the square of the distance
+// within which track points are near to it.
+func (c *NearCmd) area(gc geocode.Geocoder, place string) (q3 geomath.Point3, d2 float64, err error) {
 	r, err := gc.Geocode(place)
 	if err != nil {
-		return err
+		return geomath.Point3{}, 0, err
 	}
 
 	if cli.verbose {
 		fmt.Printf("Geocode result for %s is:\n%+v\n", place, r)
 	}
 
-	q3 := geomath.Pt3(r.Lat, r.Long)
+	q3 = geomath.Pt3(r.Lat, r.Long)
 	ne := geomath.Pt3(r.North, r.East)
 	sw := geomath.Pt3(r.South, r.West)
 
-	d2 := c.dist * c.dist
+	d2 = c.dist * c.dist
 	if x := dist3sq(q3, ne); x > d2 {
 		d2 = x
 	}
@@ -80,23 +107,29 @@ func (c *NearCmd) find(gc geocode.Geocoder, place string, trk track.Track) error
 		fmt.Printf("d = %.2f\n", math.Sqrt(d2))
 	}
 
-	in := false
-	for _, p := range trk {
-		p3 := pt3(p)
-		d := p3.Sub(q3)
-		dd := d.Dot(d)
-		nextin := dd <= d2
-		if in != nextin {
-			in = nextin
-			if in {
-				fmt.Println("enter>", p.Time())
-			} else {
-				fmt.Println("leave<", p.Time())
-			}
+	return q3, d2, nil
+}
+
+// nearFinder prints the times entering and leaving an area
+// for track points in chronological order.
+type nearFinder struct {
+	q3 geomath.Point3 // area center
+	d2 float64        // squared area radius
+
+	in bool
+}
+
+func (f *nearFinder) point(p track.Point) {
+	d := pt3(p).Sub(f.q3)
+	nextin := d.Dot(d) <= f.d2
+	if f.in != nextin {
+		f.in = nextin
+		if f.in {
+			fmt.Println("enter>", p.Time())
+		} else {
+			fmt.Println("leave<", p.Time())
 		}
 	}
-
-	return nil
 }
 
 func dist3sq(a, b geomath.Point3) float64 {
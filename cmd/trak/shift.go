@@ -53,13 +53,9 @@ func (c *ShiftCmd) Run(args []string) error {
 		return err
 	}
 
-	var trk track.Track
-	for _, fn := range args[2:] {
-		seg, err := load(fn)
-		if err != nil {
-			return err
-		}
-		trk.Merge(trackTrack(seg))
+	trk, err := loadAll(args[2:], t0.Add(-c.dt), t0.Add(c.dt))
+	if err != nil {
+		return err
 	}
 
 	trk = trackTimeRange(trk, t0.Add(-c.dt), t0.Add(c.dt))
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/tajtiattila/cmdmain"
+	"github.com/tajtiattila/track/trackio"
+	"github.com/tajtiattila/track/trackstore"
+)
+
+type StoreCmd struct {
+	partition string
+}
+
+func init() {
+	cmdmain.Register("store", func(flags *flag.FlagSet) cmdmain.Command {
+		c := new(StoreCmd)
+		flags.StringVar(&c.partition, "partition", "day", "partition of a new store, day or month")
+		return c
+	})
+}
+
+func (*StoreCmd) Describe() string {
+	return "Add tracks to a track store, creating the store if needed."
+}
+
+func (*StoreCmd) ArgNames() string {
+	return "[store] [paths...]"
+}
+
+func (c *StoreCmd) Run(args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("need a store directory and at least one track file")
+	}
+
+	s, err := c.open(args[0])
+	if err != nil {
+		return err
+	}
+
+	for _, fn := range args[1:] {
+		d, closer, err := openDecoder(fn, time.Time{}, time.Time{})
+		if err != nil {
+			return err
+		}
+
+		if cli.inacc {
+			d.Accuracy = trackio.NoAccuracy
+		}
+		n, err := s.Ingest(cli.ctx, d)
+		endProgress(d)
+		closer.Close()
+		if err != nil {
+			return fileError(fn, err)
+		}
+
+		fmt.Printf("%s: %d points added\n", fn, n)
+	}
+
+	return nil
+}
+
+func (c *StoreCmd) open(dir string) (*trackstore.Store, error) {
+	if trackstore.IsStore(dir) {
+		return trackstore.Open(dir)
+	}
+
+	p, err := trackstore.ParsePartition(c.partition)
+	if err != nil {
+		return nil, err
+	}
+	return trackstore.Create(dir, p)
+}
@@ -1,14 +1,41 @@
 package main
 
-import "github.com/tajtiattila/cmdmain"
+import (
+	"context"
+	"os"
+	"os/signal"
+
+	"github.com/tajtiattila/cmdmain"
+)
 
 var cli struct {
-	verbose bool
-	inacc   bool
+	verbose  bool
+	inacc    bool
+	format   string
+	progress bool
+	merge    string
+
+	csvcols, csvtime string
+
+	// ctx is canceled on interrupt to stop decoding.
+	ctx context.Context
 }
 
 func main() {
 	cmdmain.Globals.BoolVar(&cli.verbose, "v", false, "verbose mode")
 	cmdmain.Globals.BoolVar(&cli.inacc, "inacc", false, "skip accuracy checks when loading tracks")
+	cmdmain.Globals.StringVar(&cli.format, "format", "", "input track format (default detected from input)")
+	cmdmain.Globals.BoolVar(&cli.progress, "progress", false, "show decoding progress on stderr")
+	cmdmain.Globals.StringVar(&cli.csvcols, "csvcols", "",
+		"columns of csv input without header row, such as time,lat,lon (implies -format csv)")
+	cmdmain.Globals.StringVar(&cli.csvtime, "csvtime", "",
+		"time layout of csv input, unix, unixms or a Go time layout (implies -format csv)")
+	cmdmain.Globals.StringVar(&cli.merge, "merge", "replace",
+		"policy to merge overlapping tracks: replace, keep, interleave or accuracy")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	cli.ctx = ctx
+
 	cmdmain.Main()
 }
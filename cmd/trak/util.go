@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -12,40 +13,214 @@ import (
 	"github.com/tajtiattila/track"
 	"github.com/tajtiattila/track/geomath"
 	"github.com/tajtiattila/track/trackio"
+	"github.com/tajtiattila/track/trackstore"
 )
 
-func load(fn string) (trackio.Track, error) {
-	f, err := os.Open(fn)
+// loadAll loads and merges the track files, track stores
+// or photo directories in paths concurrently.
+//
+// Track stores are read only around start and end, see openDecoder.
+//
+// Files failing to load are reported on stderr and skipped.
+// An error is returned only if no file could be loaded.
+func loadAll(paths []string, start, end time.Time) (track.Track, error) {
+	policy, err := mergePolicy(cli.merge)
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
 
-	d := trackio.NewDecoder(f)
+	l := &trackio.Loader{
+		Policy: policy,
+		Open: func(fn string) (*trackio.Decoder, io.Closer, error) {
+			d, closer, err := openDecoder(fn, start, end)
+			if err != nil {
+				return nil, nil, err
+			}
+			// progress lines of concurrent loads would be garbled
+			d.ReportProgress = nil
+			if cli.inacc {
+				d.Accuracy = trackio.NoAccuracy
+			}
+			return d, closer, nil
+		},
+	}
+
+	trk, errs := l.Load(cli.ctx, paths)
+	if err := cli.ctx.Err(); err != nil {
+		return nil, err
+	}
+	for _, e := range errs {
+		var err error = e
+		if cli.verbose {
+			err = fileError(e.Path, e.Err)
+		}
+		fmt.Fprintln(os.Stderr, err)
+	}
+	if len(errs) != 0 && len(errs) == len(paths) {
+		return nil, fmt.Errorf("no track loaded")
+	}
+	return trk, nil
+}
+
+// mergePolicy returns the merge policy for the -merge flag value s.
+func mergePolicy(s string) (track.MergePolicy, error) {
+	for _, p := range []track.MergePolicy{
+		track.Replace,
+		track.KeepExisting,
+		track.Interleave,
+		track.PreferAccuracy,
+	} {
+		if s == p.String() {
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown merge policy %q", s)
+}
+
+// loadDecoder loads the track file fn, and returns the decoder used
+// as well, to access data other than track points such as metadata.
+//
+// If fn is a directory, the track is decoded from the track store,
+// or from the geotagged photos within.
+// Track stores are read only around start and end, see openDecoder.
+func loadDecoder(fn string, start, end time.Time) (trackio.Track, *trackio.Decoder, error) {
+	d, closer, err := openDecoder(fn, start, end)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer closer.Close()
+
 	if cli.inacc {
 		d.Accuracy = trackio.NoAccuracy
 	}
-	return d.Track()
+	trk, err := d.TrackContext(cli.ctx)
+	endProgress(d)
+	return trk, d, fileError(fn, err)
 }
 
 func loadRaw(fn string) (trackio.Track, error) {
-	f, err := os.Open(fn)
+	d, closer, err := openDecoder(fn, time.Time{}, time.Time{})
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
+	defer closer.Close()
 
-	d := trackio.NewDecoder(f)
 	d.Accuracy = trackio.NoAccuracy
-	return d.Track()
+	trk, err := d.TrackContext(cli.ctx)
+	endProgress(d)
+	return trk, fileError(fn, err)
 }
 
-func trackTrack(t0 trackio.Track) track.Track {
-	trk := make(track.Track, len(t0))
-	for i, p := range t0 {
-		trk[i] = track.Pt(p.Time, p.Lat, p.Long)
+// storeMargin is the time added around the range read from
+// track stores, so that positions can be interpolated
+// at the boundaries of the range.
+const storeMargin = 24 * time.Hour
+
+// openDecoder returns a decoder for the track file,
+// track store or photo directory fn.
+//
+// Only the points of track stores between start-storeMargin
+// and end+storeMargin are read. A zero start or end
+// means the range is unbounded in that direction.
+//
+// The format of track files is detected unless the -format flag is used.
+func openDecoder(fn string, start, end time.Time) (*trackio.Decoder, io.Closer, error) {
+	reg, format, err := openRegistry()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !start.IsZero() {
+		start = start.Add(-storeMargin)
+	}
+	if !end.IsZero() {
+		end = end.Add(storeMargin)
+	}
+	trackstore.RegisterDir(reg, start, end)
+
+	d, closer, err := reg.OpenFileFormat(fn, format)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if cli.progress {
+		fi, err := os.Stat(fn)
+		if err != nil {
+			closer.Close()
+			return nil, nil, err
+		}
+		d.ReportProgress = progressFunc(fn, fi)
+	}
+	return d, closer, nil
+}
+
+// openRegistry returns a copy of the registry used to open
+// track files and directories, and the name of the format
+// specified with the -format flag, if any.
+//
+// CSV input is decoded with the settings of the -csvcols
+// and -csvtime flags, if any.
+func openRegistry() (*trackio.Registry, string, error) {
+	reg := trackio.DefaultRegistry.Clone()
+	if cli.csvcols == "" && cli.csvtime == "" {
+		return reg, cli.format, nil
+	}
+
+	if cli.format != "" && cli.format != "csv" {
+		return nil, "", fmt.Errorf("-csvcols and -csvtime need csv format")
+	}
+
+	c := &trackio.CSV{TimeLayout: cli.csvtime}
+	if cli.csvcols != "" {
+		cols, err := trackio.ParseCSVColumns(cli.csvcols)
+		if err != nil {
+			return nil, "", err
+		}
+		c.Columns = cols
+	}
+
+	// the default csv format can't be replaced,
+	// so start with an empty registry
+	reg = new(trackio.Registry)
+	if err := reg.RegisterFormat("csv", nil, c.NewPointReader); err != nil {
+		return nil, "", err
+	}
+	return reg, "csv", nil
+}
+
+// progressFunc returns a function showing
+// the decoding progress of fn on stderr.
+func progressFunc(fn string, fi os.FileInfo) func(trackio.Progress) {
+	const mib = 1 << 20
+	return func(p trackio.Progress) {
+		var size string
+		if !fi.IsDir() && fi.Size() > 0 {
+			size = fmt.Sprintf(" of %.1f MiB (%.0f%%)",
+				float64(fi.Size())/mib, 100*float64(p.Bytes)/float64(fi.Size()))
+		}
+		fmt.Fprintf(os.Stderr, "\r%s: %.1f MiB%s, %d points, %d rejected, %d errors",
+			fn, float64(p.Bytes)/mib, size, p.Accepted, p.Rejected, p.Errors)
+	}
+}
+
+// endProgress ends the progress line of d, if any.
+func endProgress(d *trackio.Decoder) {
+	if d.ReportProgress != nil {
+		fmt.Fprintln(os.Stderr)
+	}
+}
+
+// fileError adds the file name fn to decode errors in verbose mode,
+// along with the position of the invalid record, if known.
+func fileError(fn string, err error) error {
+	de, ok := err.(*trackio.DecodeError)
+	if !ok || !cli.verbose {
+		return err
+	}
+	if pos := de.Position(); pos != "" {
+		return fmt.Errorf("%s: %v (%s)", fn, err, pos)
 	}
-	return trk
+	return fmt.Errorf("%s: %v", fn, err)
 }
 
 func check(err error) {
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tajtiattila/cmdmain"
+	"github.com/tajtiattila/track"
+	"github.com/tajtiattila/track/trackio"
+)
+
+type WhereCmd struct{}
+
+func init() {
+	cmdmain.Register("where", func(flags *flag.FlagSet) cmdmain.Command {
+		return new(WhereCmd)
+	})
+}
+
+func (*WhereCmd) Describe() string {
+	return "Show place visits, activities and position at a given time."
+}
+
+func (*WhereCmd) ArgNames() string {
+	return "[time] [paths...]"
+}
+
+func (c *WhereCmd) Run(args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("where needs a time and at least one track file")
+	}
+
+	t, prec, err := argTimePrec(args[0])
+	if err != nil {
+		return errors.Wrap(err, "invalid time")
+	}
+	start, end := t, t
+	if prec < 5 {
+		// show everything within the given day, month...
+		start, end = timeRange(t, prec)
+	}
+
+	policy, err := mergePolicy(cli.merge)
+	if err != nil {
+		return err
+	}
+
+	var segs []track.Segmented
+	var visits []trackio.Visit
+	var acts []trackio.ActivitySegment
+	for _, fn := range args[1:] {
+		seg, d, err := loadDecoder(fn, start, end)
+		if err != nil {
+			return err
+		}
+		segs = append(segs, seg.Segmented())
+		visits = append(visits, d.Visits()...)
+		acts = append(acts, d.ActivitySegments()...)
+	}
+
+	found := false
+	for _, v := range visits {
+		if overlaps(v.Start, v.End, start, end) {
+			found = true
+			fmt.Printf("visit    %s %s  %s (%.6f, %.6f)\n",
+				fmtTime(v.Start), fmtTime(v.End), placeName(v), v.Lat, v.Long)
+		}
+	}
+	for _, a := range acts {
+		if overlaps(a.Start, a.End, start, end) {
+			found = true
+			fmt.Printf("activity %s %s  %s %.0fm\n",
+				fmtTime(a.Start), fmtTime(a.End), a.Type, a.Distance)
+		}
+	}
+
+	// don't interpolate across gaps between track segments
+	trk := track.MergeSegmented(policy, segs...)
+	if lat, long, ok := trk.AtSegment(t); ok {
+		found = true
+		fmt.Printf("position %s  %.6f %.6f\n", fmtTime(t), lat, long)
+	}
+
+	if !found {
+		return fmt.Errorf("no data for %s", args[0])
+	}
+	return nil
+}
+
+func overlaps(s0, e0, s1, e1 time.Time) bool {
+	return !e0.Before(s1) && !e1.Before(s0)
+}
+
+func placeName(v trackio.Visit) string {
+	switch {
+	case v.Name != "" && v.Address != "":
+		return v.Name + ", " + v.Address
+	case v.Name != "":
+		return v.Name
+	case v.Address != "":
+		return v.Address
+	}
+	return "unknown place"
+}
+
+func fmtTime(t time.Time) string {
+	return t.Local().Format("2006-01-02 15:04")
+}
@@ -4,10 +4,62 @@ import (
 	"sort"
 )
 
+// MergePolicy specifies how points are merged
+// where a track and a segment overlap in time.
+type MergePolicy int
+
+const (
+	// Replace replaces the points of the track
+	// within the time range of the segment.
+	Replace MergePolicy = iota
+
+	// KeepExisting keeps the points of the track
+	// within the time range of the segment, and adds
+	// only the points of the segment before or after them.
+	KeepExisting
+
+	// Interleave keeps the points of both the track
+	// and the segment. Points of the segment identical to
+	// a point of the track are dropped.
+	Interleave
+
+	// PreferAccuracy uses Replace if the points of the segment
+	// are more accurate than the points of the track within
+	// its time range, and KeepExisting otherwise.
+	//
+	// The mean accuracy of points is compared, where points
+	// with unknown accuracy are counted as having MaxAcc.
+	PreferAccuracy
+)
+
+func (p MergePolicy) String() string {
+	switch p {
+	case Replace:
+		return "replace"
+	case KeepExisting:
+		return "keep"
+	case Interleave:
+		return "interleave"
+	case PreferAccuracy:
+		return "accuracy"
+	}
+	return "invalid"
+}
+
 // Merge updates *ptrk by replacing its track points
 // between seg.StartTime() and seg.EndTime() with
 // track points from seg.
+//
+// It is equivalent to ptrk.MergeWith(seg, Replace).
 func (ptrk *Track) Merge(seg Track) {
+	ptrk.MergeWith(seg, Replace)
+}
+
+// MergeWith updates *ptrk by merging seg into it
+// using the specified policy.
+//
+// Seg must be in chronological order.
+func (ptrk *Track) MergeWith(seg Track, policy MergePolicy) {
 	if len(seg) == 0 {
 		return
 	}
@@ -30,6 +82,44 @@ func (ptrk *Track) Merge(seg Track) {
 		return se < trk[i].t
 	})
 
+	if si == ei {
+		// no points within the time range of seg
+		policy = Replace
+	}
+
+	if policy == PreferAccuracy {
+		if meanAcc(seg) < meanAcc(trk[si:ei]) {
+			policy = Replace
+		} else {
+			policy = KeepExisting
+		}
+	}
+
+	switch policy {
+	case KeepExisting:
+		// keep seg only before and after trk[si:ei]
+		ks := sort.Search(len(seg), func(i int) bool {
+			return trk[si].t <= seg[i].t
+		})
+		ke := sort.Search(len(seg), func(i int) bool {
+			return trk[ei-1].t < seg[i].t
+		})
+		tt := make(Track, 0, len(trk)+ks+len(seg)-ke)
+		tt = append(tt, trk[:si]...)
+		tt = append(tt, seg[:ks]...)
+		tt = append(tt, trk[si:ei]...)
+		tt = append(tt, seg[ke:]...)
+		*ptrk = append(tt, trk[ei:]...)
+		return
+
+	case Interleave:
+		tt := make(Track, 0, len(trk)+len(seg))
+		tt = append(tt, trk[:si]...)
+		tt = interleave(tt, trk[si:ei], seg)
+		*ptrk = append(tt, trk[ei:]...)
+		return
+	}
+
 	var tt Track
 	o := si + len(seg)
 	n := o + len(trk[ei:])
@@ -47,3 +137,41 @@ func (ptrk *Track) Merge(seg Track) {
 
 	*ptrk = tt
 }
+
+// interleave appends the points of a and b to dst
+// in chronological order, and returns the result.
+//
+// Points of a come first if they have the same time as points of b,
+// and points of b identical to a point in a are dropped.
+func interleave(dst, a, b Track) Track {
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case b[j].t < a[i].t:
+			dst = append(dst, b[j])
+			j++
+		case b[j] == a[i]:
+			// duplicate
+			j++
+		default:
+			dst = append(dst, a[i])
+			i++
+		}
+	}
+	dst = append(dst, a[i:]...)
+	return append(dst, b[j:]...)
+}
+
+// meanAcc returns the mean accuracy of the points of trk,
+// counting points with unknown accuracy as having MaxAcc.
+func meanAcc(trk Track) float64 {
+	var sum float64
+	for _, p := range trk {
+		acc, ok := p.Acc()
+		if !ok {
+			acc = MaxAcc
+		}
+		sum += acc
+	}
+	return sum / float64(len(trk))
+}
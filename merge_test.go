@@ -1,6 +1,7 @@
 package track_test
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
@@ -81,3 +82,99 @@ func TestMerge(t *testing.T) {
 		gen.trk(60, 30, 1.0),
 		30, 0)
 }
+
+func TestMergePolicy(t *testing.T) {
+	epoch := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// gen returns a track of n points every minute starting
+	// at minute start, with latitude lat and accuracy acc.
+	gen := func(start, n int, lat, acc float64) track.Track {
+		var trk track.Track
+		for i := 0; i < n; i++ {
+			ts := epoch.Add(time.Duration(start+i) * time.Minute)
+			p := track.Pt(ts, lat, 19)
+			if acc >= 0 {
+				p = p.WithAcc(acc)
+			}
+			trk = append(trk, p)
+		}
+		return trk
+	}
+
+	// lats returns the latitudes of trk as a string.
+	lats := func(trk track.Track) string {
+		var s string
+		for _, p := range trk {
+			s += fmt.Sprint(p.Lat())
+		}
+		return s
+	}
+
+	tests := []struct {
+		policy   track.MergePolicy
+		trk, seg track.Track
+		want     string
+	}{
+		{
+			track.Replace,
+			gen(0, 5, 1, 5),
+			gen(2, 5, 2, 50),
+			"1122222",
+		},
+		{
+			track.KeepExisting,
+			gen(0, 5, 1, 5),
+			gen(2, 5, 2, 50),
+			"1111122",
+		},
+		{
+			track.KeepExisting,
+			gen(2, 2, 1, 5),
+			gen(0, 6, 2, 50),
+			"221122",
+		},
+		{
+			track.Interleave,
+			gen(0, 3, 1, -1),
+			append(gen(1, 1, 1, -1), gen(2, 2, 2, -1)...),
+			"11122",
+		},
+		{
+			track.PreferAccuracy,
+			gen(0, 5, 1, 5),
+			gen(2, 5, 2, 50),
+			"1111122",
+		},
+		{
+			track.PreferAccuracy,
+			gen(0, 5, 1, 50),
+			gen(2, 5, 2, 5),
+			"1122222",
+		},
+		{
+			track.PreferAccuracy,
+			gen(0, 5, 1, -1),
+			gen(2, 5, 2, 500),
+			"1122222",
+		},
+		{
+			track.PreferAccuracy,
+			gen(0, 5, 1, -1),
+			gen(10, 2, 2, -1),
+			"1111122",
+		},
+	}
+
+	for i, tt := range tests {
+		trk := tt.trk
+		trk.MergeWith(tt.seg, tt.policy)
+		if got := lats(trk); got != tt.want {
+			t.Errorf("test %d (%v): got %s, want %s", i, tt.policy, got, tt.want)
+		}
+		for j := 1; j < len(trk); j++ {
+			if trk[j].Time().Before(trk[j-1].Time()) {
+				t.Errorf("test %d (%v): point %d out of order", i, tt.policy, j)
+			}
+		}
+	}
+}
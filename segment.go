@@ -0,0 +1,101 @@
+package track
+
+import (
+	"sort"
+	"time"
+
+	"github.com/tajtiattila/track/trackutil"
+)
+
+// Segmented is a track having segment boundaries,
+// such as separate recordings or gaps due to signal loss.
+type Segmented struct {
+	Track
+
+	// Starts holds the indices of points in Track
+	// starting a new segment, in increasing order.
+	//
+	// The first point always starts a segment,
+	// therefore index 0 need not be present.
+	Starts []int
+}
+
+// SegmentStart reports whether the point at index i
+// starts a new segment.
+func (s Segmented) SegmentStart(i int) bool {
+	if i == 0 {
+		return true
+	}
+	j := sort.SearchInts(s.Starts, i)
+	return j < len(s.Starts) && s.Starts[j] == i
+}
+
+// HasSegmentTime checks if s has a position for the given time
+// within one of its segments.
+//
+// Unlike HasTime, it returns false if t falls between two segments.
+func (s Segmented) HasSegmentTime(t time.Time) bool {
+	_, _, ok := trackutil.LookupSegment(s, t)
+	return ok
+}
+
+// AtSegment calculates the interpolated lat and long
+// of s for the given time.
+//
+// Unlike At, it does not interpolate between segments.
+// It returns ok == false if s.HasSegmentTime(t) is false.
+func (s Segmented) AtSegment(t time.Time) (lat, long float64, ok bool) {
+	return trackutil.LookupSegment(s, t)
+}
+
+// Segments returns the segments of s.
+func (s Segmented) Segments() []Track {
+	var v []Track
+	start := 0
+	for _, i := range s.Starts {
+		if i > start && i <= len(s.Track) {
+			v = append(v, s.Track[start:i])
+			start = i
+		}
+	}
+	if start < len(s.Track) {
+		v = append(v, s.Track[start:])
+	}
+	return v
+}
+
+// MergeSegmented merges segs using policy as Track.MergeWith does.
+//
+// A point of the result starts a new segment unless it and
+// the point before it are within the time span of a single segment
+// of one of segs, so that the result is not interpolated
+// across gaps that are present in all of segs.
+func MergeSegmented(policy MergePolicy, segs ...Segmented) Segmented {
+	type span struct{ start, end int64 }
+
+	var trk Track
+	var spans []span
+	for _, s := range segs {
+		trk.MergeWith(s.Track, policy)
+		for _, seg := range s.Segments() {
+			spans = append(spans, span{seg[0].t, seg[len(seg)-1].t})
+		}
+	}
+
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	m := Segmented{Track: trk}
+	var end int64 // latest end of spans starting before the current point
+	k := 0
+	for i := 1; i < len(trk); i++ {
+		for ; k < len(spans) && spans[k].start <= trk[i-1].t; k++ {
+			if spans[k].end > end {
+				end = spans[k].end
+			}
+		}
+		if k == 0 || end < trk[i].t {
+			m.Starts = append(m.Starts, i)
+		}
+	}
+	return m
+}
@@ -0,0 +1,81 @@
+package track_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tajtiattila/track"
+)
+
+func TestSegmented(t *testing.T) {
+	epoch := time.Date(2010, 6, 1, 10, 30, 0, 0, time.UTC)
+
+	trk := timeTrkGen(epoch, -10, -10).trk(10, 0, 1)
+	s := track.Segmented{Track: trk, Starts: []int{4, 7}}
+
+	if v := s.Segments(); len(v) != 3 || len(v[0]) != 4 || len(v[1]) != 3 || len(v[2]) != 3 {
+		t.Fatalf("got segments %v", v)
+	}
+
+	var tests = []struct {
+		what string
+		at   time.Time
+		ok   bool
+	}{
+		{"start", epoch, true},
+		{"within segment", epoch.Add(1500 * time.Millisecond), true},
+		{"between segments", epoch.Add(3500 * time.Millisecond), false},
+		{"segment start", epoch.Add(4 * time.Second), true},
+		{"segment end", epoch.Add(6 * time.Second), true},
+		{"end", epoch.Add(9 * time.Second), true},
+		{"before start", epoch.Add(-time.Second), false},
+		{"after end", epoch.Add(time.Hour), false},
+	}
+
+	for _, tt := range tests {
+		if _, _, ok := s.AtSegment(tt.at); ok != tt.ok {
+			t.Errorf("%s: got %v, want %v", tt.what, ok, tt.ok)
+		}
+		if ok := s.HasSegmentTime(tt.at); ok != tt.ok {
+			t.Errorf("%s: HasSegmentTime got %v, want %v", tt.what, ok, tt.ok)
+		}
+	}
+
+	lat, long, _ := s.AtSegment(epoch.Add(1500 * time.Millisecond))
+	wlat, wlong := trk.At(epoch.Add(1500 * time.Millisecond))
+	if lat != wlat || long != wlong {
+		t.Errorf("got %v,%v, want %v,%v", lat, long, wlat, wlong)
+	}
+}
+
+func TestMergeSegmented(t *testing.T) {
+	epoch := time.Date(2010, 6, 1, 10, 30, 0, 0, time.UTC)
+	trk := timeTrkGen(epoch, -10, -10).trk(10, 0, 1)
+
+	// a has a gap between 3s and 6s, b has one between 2s and 4s
+	a := track.Segmented{Track: append(trk[:4:4], trk[6:]...), Starts: []int{4}}
+	b := track.Segmented{Track: trk[1:5], Starts: []int{2}}
+
+	m := track.MergeSegmented(track.Interleave, a, b)
+	if len(m.Track) != 9 {
+		t.Fatalf("got %d points, want 9", len(m.Track))
+	}
+
+	var tests = []struct {
+		what string
+		at   time.Time
+		ok   bool
+	}{
+		{"within a", epoch.Add(500 * time.Millisecond), true},
+		{"gap of b within a", epoch.Add(2500 * time.Millisecond), true},
+		{"gap of a within b", epoch.Add(3500 * time.Millisecond), true},
+		{"gap of both", epoch.Add(4500 * time.Millisecond), false},
+		{"after gap", epoch.Add(6500 * time.Millisecond), true},
+	}
+
+	for _, tt := range tests {
+		if ok := m.HasSegmentTime(tt.at); ok != tt.ok {
+			t.Errorf("%s: got %v, want %v", tt.what, ok, tt.ok)
+		}
+	}
+}
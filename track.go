@@ -16,11 +16,21 @@ const coordUnit = 1e7
 // Geographical coordinates are integers of
 // the degree value multiplied by 1e7,
 // therefore have a precision of at least 0.0111 meters.
+//
+// The horizontal accuracy and the elevation of the point,
+// if known, have a precision of 0.1 meters.
+// A Point is 24 bytes in memory.
 type Point struct {
-	t         int64 // milliseconds since January 1, 1970 UTC
-	lat, long int32 // values multiplied by 1e7
+	t         int64  // milliseconds since January 1, 1970 UTC
+	lat, long int32  // values multiplied by 1e7
+	acc       uint16 // accuracy in decimeters plus one, zero if unknown
+	hasEle    bool
+	ele       int32 // elevation in decimeters
 }
 
+// MaxAcc is the largest accuracy value of a Point in meters.
+const MaxAcc = (math.MaxUint16 - 1) / 10.0
+
 // Pt returns a new track point.
 func Pt(t time.Time, lat, long float64) Point {
 	if lat < -90 {
@@ -66,6 +76,63 @@ func (p Point) Lat() float64 { return float64(p.lat) / coordUnit }
 // Long returns the geographical longitude of p in degrees.
 func (p Point) Long() float64 { return float64(p.long) / coordUnit }
 
+// Acc returns the horizontal accuracy of p in meters.
+//
+// It returns ok == false if the accuracy of p is unknown.
+func (p Point) Acc() (acc float64, ok bool) {
+	if p.acc == 0 {
+		return 0, false
+	}
+	return float64(p.acc-1) / 10, true
+}
+
+// WithAcc returns p having the horizontal accuracy acc in meters.
+//
+// Values above MaxAcc are stored as MaxAcc.
+// If acc is negative or NaN, the accuracy of the result is unknown.
+func (p Point) WithAcc(acc float64) Point {
+	switch {
+	case acc >= 0:
+		if acc > MaxAcc {
+			acc = MaxAcc
+		}
+		p.acc = uint16(acc*10+0.5) + 1
+	default:
+		p.acc = 0
+	}
+	return p
+}
+
+// Ele returns the elevation of p in meters.
+//
+// It returns ok == false if the elevation of p is unknown.
+func (p Point) Ele() (ele float64, ok bool) {
+	return float64(p.ele) / 10, p.hasEle
+}
+
+// WithEle returns p having the elevation ele in meters.
+//
+// If ele is NaN, the elevation of the result is unknown.
+func (p Point) WithEle(ele float64) Point {
+	const lim = math.MaxInt32 / 10
+	switch {
+	case math.IsNaN(ele):
+		p.hasEle, p.ele = false, 0
+		return p
+	case ele > lim:
+		ele = lim
+	case ele < -lim:
+		ele = -lim
+	}
+	p.hasEle = true
+	if ele > 0 {
+		p.ele = int32(ele*10 + 0.5)
+	} else {
+		p.ele = int32(ele*10 - 0.5)
+	}
+	return p
+}
+
 // Track is a series of track points
 // in chronological order.
 type Track []Point
@@ -0,0 +1,58 @@
+package track_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/tajtiattila/track"
+)
+
+func TestPointAcc(t *testing.T) {
+	p := track.Pt(time.Now(), 47, 19)
+	if _, ok := p.Acc(); ok {
+		t.Fatal("new point has accuracy")
+	}
+
+	for _, tt := range []struct{ acc, want float64 }{
+		{0, 0},
+		{12.34, 12.3},
+		{1e9, track.MaxAcc},
+	} {
+		got, ok := p.WithAcc(tt.acc).Acc()
+		if !ok || got != tt.want {
+			t.Errorf("accuracy %v: got %v, want %v", tt.acc, got, tt.want)
+		}
+	}
+
+	if _, ok := p.WithAcc(5).WithAcc(-1).Acc(); ok {
+		t.Fatal("point has accuracy after removal")
+	}
+}
+
+func TestPointEle(t *testing.T) {
+	p := track.Pt(time.Now(), 47, 19).WithAcc(5)
+	if _, ok := p.Ele(); ok {
+		t.Fatal("new point has elevation")
+	}
+
+	for _, tt := range []struct{ ele, want float64 }{
+		{0, 0},
+		{123.44, 123.4},
+		{-12.36, -12.4},
+		{8848.86, 8848.9},
+	} {
+		q := p.WithEle(tt.ele)
+		got, ok := q.Ele()
+		if !ok || got != tt.want {
+			t.Errorf("elevation %v: got %v, want %v", tt.ele, got, tt.want)
+		}
+		if acc, _ := q.Acc(); acc != 5 {
+			t.Errorf("elevation %v: got accuracy %v, want 5", tt.ele, acc)
+		}
+	}
+
+	if _, ok := p.WithEle(100).WithEle(math.NaN()).Ele(); ok {
+		t.Fatal("point has elevation after removal")
+	}
+}
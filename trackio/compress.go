@@ -0,0 +1,164 @@
+package trackio
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+)
+
+// maxCompressDepth is the maximum number of nested
+// compression layers unwrapped by uncompress.
+const maxCompressDepth = 4
+
+// uncompress returns a reader yielding the uncompressed content of r.
+//
+// Gzip and bzip2 streams are uncompressed.
+// For zip archives (including KMZ files), the content of
+// the first file of a known format is returned,
+// using doc.kml in the archive root if it exists.
+//
+// If r is not compressed, a reader yielding
+// the original content of r is returned.
+//
+// Detect reports if the prefix of a file within
+// a zip archive has a format to be decoded.
+func uncompress(r io.Reader, detect DetectFunc) (io.Reader, error) {
+	for i := 0; i < maxCompressDepth; i++ {
+		if zr, ok, err := openSeekableZip(r); ok || err != nil {
+			if err != nil {
+				return nil, err
+			}
+			r, err = zipContent(zr, detect)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		br := bufio.NewReader(r)
+		magic, _ := br.Peek(4)
+
+		switch {
+
+		case bytes.HasPrefix(magic, []byte("\x1f\x8b")):
+			gz, err := gzip.NewReader(br)
+			if err != nil {
+				return nil, err
+			}
+			r = gz
+
+		case bytes.HasPrefix(magic, []byte("BZh")):
+			r = bzip2.NewReader(br)
+
+		case bytes.HasPrefix(magic, []byte("PK\x03\x04")):
+			// zip archive needs random access
+			data, err := ioutil.ReadAll(br)
+			if err != nil {
+				return nil, err
+			}
+			zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+			if err != nil {
+				return nil, err
+			}
+			r, err = zipContent(zr, detect)
+			if err != nil {
+				return nil, err
+			}
+
+		default:
+			return br, nil
+		}
+	}
+
+	return nil, fmt.Errorf("trackio: too many compression layers")
+}
+
+type readSeekerAt interface {
+	io.ReaderAt
+	io.Seeker
+}
+
+// openSeekableZip opens r as a zip archive without reading it into memory,
+// if r is an io.ReaderAt and io.Seeker (such as *os.File).
+func openSeekableZip(r io.Reader) (zr *zip.Reader, ok bool, err error) {
+	rs, ok := r.(readSeekerAt)
+	if !ok {
+		return nil, false, nil
+	}
+
+	ofs, err := rs.Seek(0, io.SeekCurrent)
+	if err != nil {
+		// not seekable after all, eg. a pipe
+		return nil, false, nil
+	}
+
+	// don't count the probe as input read
+	probe := rs
+	if c, ok := rs.(*countingReaderAt); ok {
+		probe = c.rs
+	}
+
+	magic := make([]byte, 4)
+	if _, err := probe.ReadAt(magic, ofs); err != nil || string(magic) != "PK\x03\x04" {
+		return nil, false, nil
+	}
+
+	end, err := rs.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, false, err
+	}
+
+	zr, err = zip.NewReader(io.NewSectionReader(rs, ofs, end-ofs), end-ofs)
+	return zr, true, err
+}
+
+// zipContent returns the content of the file in zr to be decoded.
+func zipContent(zr *zip.Reader, detect DetectFunc) (io.Reader, error) {
+	for _, f := range zr.File {
+		if f.Name == "doc.kml" {
+			return zipFileContent(f)
+		}
+	}
+
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+
+		switch path.Ext(f.Name) {
+		case ".gz", ".bz2", ".zip", ".kmz":
+			// nested compression, assume it holds a track
+			return zipFileContent(f)
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		buf, err := ioutil.ReadAll(io.LimitReader(rc, 64<<10))
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		if detect(buf) {
+			return zipFileContent(f)
+		}
+	}
+
+	return nil, ErrFormat
+}
+
+// zipFileContent returns the content of f.
+//
+// The file is never closed, but it holds
+// no resources other than memory.
+func zipFileContent(f *zip.File) (io.Reader, error) {
+	return f.Open()
+}
@@ -0,0 +1,117 @@
+package trackio_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/tajtiattila/track/trackio"
+)
+
+// sampleBzip2 is a bzip2 compressed Google location history JSON
+// with a single point.
+var sampleBzip2 = "\x42\x5a\x68\x39\x31\x41\x59\x26\x53\x59\xc0\xa7\x03\x08\x00\x00" +
+	"\x35\x5f\x80\x30\x10\x50\x04\x66\xf0\x02\x02\x00\x0a\x2e\xa7\xce" +
+	"\x0a\x20\x00\x54\x34\x93\x46\x34\x83\x40\x00\xd3\x65\x06\xa6\x89" +
+	"\xe5\x34\xd1\xa3\x27\xa8\x03\x40\x23\x10\x7b\xf2\x9c\x9e\xc2\x10" +
+	"\x23\x5f\x12\xe6\xc8\x36\x04\x1f\x18\xb0\x09\x4c\x8b\x17\x38\xd0" +
+	"\x4e\xab\x1a\xa1\x6b\x63\x5c\x4e\xe4\x52\xa0\x9d\x4d\x14\x4c\x22" +
+	"\xbc\x23\x2e\x25\x53\x11\x0d\x84\x16\x5d\x99\x87\x88\x89\x50\xbf" +
+	"\x17\x72\x45\x38\x50\x90\xc0\xa7\x03\x08"
+
+func gzipData(t *testing.T, s string) []byte {
+	buf := new(bytes.Buffer)
+	w := gzip.NewWriter(buf)
+	if _, err := io.WriteString(w, s); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+type zipEntry struct {
+	name, data string
+}
+
+func zipData(t *testing.T, entries ...zipEntry) []byte {
+	buf := new(bytes.Buffer)
+	w := zip.NewWriter(buf)
+	for _, e := range entries {
+		f, err := w.Create(e.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(f, e.data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestCompressed(t *testing.T) {
+	tests := []struct {
+		what    string
+		data    []byte
+		wantLen int
+	}{
+		{"gzip", gzipData(t, sampleGPX), 3},
+		{"bzip2", []byte(sampleBzip2), 1},
+		{"kmz", zipData(t,
+			zipEntry{"files/icon.png", "\x89PNG\r\n\x1a\n"},
+			zipEntry{"doc.kml", sampleKML},
+		), 29},
+		{"zip", zipData(t,
+			zipEntry{"Takeout/index.html", "<html></html>"},
+			zipEntry{"Takeout/Location History/Location History.json", sampleGoogleJSON},
+		), 18},
+		{"gzip in zip", zipData(t,
+			zipEntry{"track.gpx.gz", string(gzipData(t, sampleGPX))},
+		), 3},
+	}
+
+	for _, tt := range tests {
+		trk, err := trackio.NewDecoder(bytes.NewReader(tt.data)).Track()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.what, err)
+		}
+		if len(trk) != tt.wantLen {
+			t.Fatalf("%s: track length mismatch: want %d got %d", tt.what, tt.wantLen, len(trk))
+		}
+	}
+}
+
+func TestCompressedFile(t *testing.T) {
+	f, err := ioutil.TempFile("", "trackio-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	data := zipData(t, zipEntry{"doc.kml", sampleKML})
+	if _, err := f.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+
+	trk, err := trackio.NewDecoder(f).Track()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const wantLen = 29
+	if len(trk) != wantLen {
+		t.Fatalf("track length mismatch: want %d got %d", wantLen, len(trk))
+	}
+}
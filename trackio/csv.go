@@ -0,0 +1,455 @@
+package trackio
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	RegisterFormat("csv", isCSV, new(CSV).NewPointReader)
+	RegisterEncoder("csv", new(CSV).NewPointWriter)
+}
+
+/* CSV Format:
+
+time,lat,lon,ele,acc
+2018-01-01T10:00:00Z,47.5,19.05,120,5
+2018-01-01T10:00:05Z,47.5001,19.0501,,
+
+Columns may be in any order. Track files registered as "csv"
+must have a header row with column names known by CSV.
+
+*/
+
+// Point field names used for CSV columns.
+const (
+	CSVTime = "time" // Point.Time
+	CSVLat  = "lat"  // Point.Lat
+	CSVLong = "lon"  // Point.Long
+	CSVAcc  = "acc"  // Point.Acc
+	CSVEle  = "ele"  // Point.Ele.Float64
+	CSVVAcc = "vacc" // Point.Ele.Acc
+)
+
+// Special CSV time layouts.
+const (
+	CSVUnix      = "unix"   // seconds since January 1, 1970 UTC
+	CSVUnixMilli = "unixms" // milliseconds since January 1, 1970 UTC
+)
+
+// csvNames maps known CSV header names to point field names.
+var csvNames = map[string]string{
+	"time":      CSVTime,
+	"timestamp": CSVTime,
+	"datetime":  CSVTime,
+	"date_time": CSVTime,
+	"utc":       CSVTime,
+
+	"lat":      CSVLat,
+	"latitude": CSVLat,
+
+	"lon":       CSVLong,
+	"lng":       CSVLong,
+	"long":      CSVLong,
+	"longitude": CSVLong,
+
+	"acc":                 CSVAcc,
+	"accuracy":            CSVAcc,
+	"horizontal_accuracy": CSVAcc,
+
+	"ele":       CSVEle,
+	"elevation": CSVEle,
+	"alt":       CSVEle,
+	"altitude":  CSVEle,
+
+	"vacc":              CSVVAcc,
+	"vertical_accuracy": CSVVAcc,
+}
+
+// CSV holds settings for decoding and encoding
+// track points as comma separated values.
+//
+// The zero value decodes CSV files having a header row
+// with known column names, and encodes all point fields
+// with a header row.
+type CSV struct {
+	// Comma is the field delimiter.
+	//
+	// If Comma is zero, it is detected from the header row
+	// when decoding, and ',' is used when encoding.
+	Comma rune
+
+	// Columns holds the point field names (such as CSVTime or CSVLat)
+	// of the columns in order. Empty names are used for
+	// columns to be ignored.
+	//
+	// When decoding, the first row is read as the header row
+	// if Columns is nil.
+	//
+	// When encoding, all point fields are written if Columns is nil.
+	// A header row is always written.
+	Columns []string
+
+	// Names maps header column names to point field names,
+	// in addition to the column names known by this package.
+	//
+	// Header column names are matched case insensitively.
+	Names map[string]string
+
+	// TimeLayout is the layout of time values
+	// as understood by time.Parse, or CSVUnix or CSVUnixMilli.
+	//
+	// When empty, RFC 3339, "2006-01-02 15:04:05"
+	// and numeric values (seconds or milliseconds
+	// since January 1, 1970 UTC) are accepted when decoding,
+	// and time.RFC3339Nano is used when encoding.
+	TimeLayout string
+}
+
+func isCSV(p []byte) bool {
+	if i := bytes.IndexByte(p, '\n'); i >= 0 {
+		p = p[:i]
+	}
+	line := string(p)
+	_, ok := new(CSV).columns(splitCSVHeader(line, detectComma(line)))
+	return ok
+}
+
+// detectComma returns the most frequent delimiter in line.
+func detectComma(line string) rune {
+	comma, n := ',', 0
+	for _, c := range ",;\t" {
+		if m := strings.Count(line, string(c)); m > n {
+			comma, n = c, m
+		}
+	}
+	return comma
+}
+
+func splitCSVHeader(line string, comma rune) []string {
+	f := strings.Split(strings.TrimSpace(line), string(comma))
+	for i := range f {
+		f[i] = strings.Trim(f[i], ` "`)
+	}
+	return f
+}
+
+// columns returns the point field names for the header row.
+//
+// It returns ok == true if at least the time, lat and lon
+// fields are present.
+func (c *CSV) columns(header []string) (cols []string, ok bool) {
+	cols = make([]string, len(header))
+	found := make(map[string]bool)
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(h))
+		name, ok := csvNames[h]
+		for k, v := range c.Names {
+			if strings.ToLower(k) == h {
+				name, ok = v, true
+			}
+		}
+		if ok {
+			cols[i] = name
+			found[name] = true
+		}
+	}
+	return cols, found[CSVTime] && found[CSVLat] && found[CSVLong]
+}
+
+// ParseCSVColumns returns the point field names for CSV.Columns
+// from s, a comma separated list of column names such as
+// "time,lat,lon,,ele".
+//
+// Column names known in CSV header rows may be used,
+// and empty names are used for columns to be ignored.
+// An error is returned for unknown column names,
+// or if the time, lat or lon column is missing.
+func ParseCSVColumns(s string) ([]string, error) {
+	var cols []string
+	found := make(map[string]bool)
+	for _, v := range strings.Split(s, ",") {
+		v = strings.ToLower(strings.TrimSpace(v))
+		if v == "" {
+			cols = append(cols, "")
+			continue
+		}
+		name, ok := csvNames[v]
+		if !ok {
+			return nil, fmt.Errorf("trackio: unknown csv column %q", v)
+		}
+		cols = append(cols, name)
+		found[name] = true
+	}
+	if !found[CSVTime] || !found[CSVLat] || !found[CSVLong] {
+		return nil, fmt.Errorf("trackio: csv columns %q have no time, lat and lon", s)
+	}
+	return cols, nil
+}
+
+// NewPointReader returns a new PointReader
+// decoding CSV data from r using the settings in c.
+func (c *CSV) NewPointReader(r io.Reader) (PointReader, error) {
+	x := &csvReader{
+		cols:   c.Columns,
+		layout: c.TimeLayout,
+	}
+
+	if x.cols != nil {
+		x.r = newCSVReader(r, c.Comma)
+		return x, nil
+	}
+
+	br := bufio.NewReader(r)
+
+	comma := c.Comma
+	if comma == 0 {
+		// detect delimiter from the header row
+		p, _ := br.Peek(4096)
+		if i := bytes.IndexByte(p, '\n'); i >= 0 {
+			p = p[:i]
+		}
+		comma = detectComma(string(p))
+	}
+
+	x.r = newCSVReader(br, comma)
+	header, err := x.r.Read()
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	cols, ok := c.columns(header)
+	if !ok {
+		return nil, decodeError("csv header %q has no time, lat and lon columns",
+			strings.Join(header, string(comma)))
+	}
+	x.cols = cols
+	return x, nil
+}
+
+func newCSVReader(r io.Reader, comma rune) *csv.Reader {
+	cr := csv.NewReader(r)
+	if comma != 0 {
+		cr.Comma = comma
+	}
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	cr.ReuseRecord = true
+	return cr
+}
+
+type csvReader struct {
+	r *csv.Reader
+
+	cols   []string
+	layout string
+
+	pos inputPos // position of the last record read
+}
+
+func (x *csvReader) inputPos() inputPos { return x.pos }
+
+func (x *csvReader) ReadPoint() (Point, error) {
+	off := x.r.InputOffset()
+	rec, err := x.r.Read()
+	if err != nil {
+		if pe, ok := err.(*csv.ParseError); ok {
+			de := newDecodeError(err)
+			de.Offset, de.Line, de.Column = off, pe.Line, pe.Column
+			err = de
+		}
+		return Point{}, err
+	}
+
+	line, col := x.r.FieldPos(0)
+	x.pos = inputPos{offset: off, line: line, column: col}
+
+	pt := Pt(time.Time{}, 0, 0)
+	var hasTime, hasLat, hasLong bool
+	for i, v := range rec {
+		if i >= len(x.cols) {
+			break
+		}
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+
+		col := x.cols[i]
+		if col == CSVTime {
+			pt.Time, err = parseCSVTime(v, x.layout)
+			if err != nil {
+				return Point{}, err
+			}
+			hasTime = true
+			continue
+		}
+
+		var f *float64
+		switch col {
+		case CSVLat:
+			f, hasLat = &pt.Lat, true
+		case CSVLong:
+			f, hasLong = &pt.Long, true
+		case CSVAcc:
+			f = &pt.Acc
+		case CSVEle:
+			f, pt.Ele.Valid = &pt.Ele.Float64, true
+		case CSVVAcc:
+			f = &pt.Ele.Acc
+		default:
+			continue
+		}
+
+		if *f, err = strconv.ParseFloat(v, 64); err != nil {
+			return Point{}, decodeError("invalid csv %s value %q", col, v)
+		}
+	}
+
+	if !hasTime || !hasLat || !hasLong {
+		return Point{}, decodeError("csv record without time or position")
+	}
+
+	return pt, nil
+}
+
+func parseCSVTime(v, layout string) (time.Time, error) {
+	switch layout {
+	case "":
+		for _, l := range []string{time.RFC3339, "2006-01-02 15:04:05"} {
+			if t, err := time.Parse(l, v); err == nil {
+				return t.UTC(), nil
+			}
+		}
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			if math.Abs(f) >= 1e11 {
+				return unixMilli(f), nil
+			}
+			return unixMilli(f * 1e3), nil
+		}
+
+	case CSVUnix:
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return unixMilli(f * 1e3), nil
+		}
+
+	case CSVUnixMilli:
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return unixMilli(f), nil
+		}
+
+	default:
+		if t, err := time.Parse(layout, v); err == nil {
+			return t.UTC(), nil
+		}
+	}
+
+	return time.Time{}, decodeError("invalid timestamp %q", v)
+}
+
+func unixMilli(ms float64) time.Time {
+	ms = math.Floor(ms + 0.5)
+	return time.Unix(0, int64(ms)*1e6).UTC()
+}
+
+// NewPointWriter returns a new PointWriter
+// encoding CSV data to w using the settings in c.
+func (c *CSV) NewPointWriter(w io.Writer) PointWriter {
+	cols := c.Columns
+	if cols == nil {
+		cols = []string{CSVTime, CSVLat, CSVLong, CSVEle, CSVAcc, CSVVAcc}
+	}
+
+	cw := csv.NewWriter(w)
+	if c.Comma != 0 {
+		cw.Comma = c.Comma
+	}
+
+	return &csvWriter{
+		w:      cw,
+		cols:   cols,
+		layout: c.TimeLayout,
+	}
+}
+
+type csvWriter struct {
+	w *csv.Writer
+
+	cols   []string
+	layout string
+
+	started bool
+	rec     []string
+}
+
+func (x *csvWriter) start() error {
+	if x.started {
+		return nil
+	}
+	x.started = true
+	x.rec = make([]string, len(x.cols))
+	return x.w.Write(x.cols)
+}
+
+func (x *csvWriter) WritePoint(p Point) error {
+	if err := x.start(); err != nil {
+		return err
+	}
+
+	for i, col := range x.cols {
+		var v string
+		switch col {
+		case CSVTime:
+			v = formatCSVTime(p.Time, x.layout)
+		case CSVLat:
+			v = fmtFloat(p.Lat)
+		case CSVLong:
+			v = fmtFloat(p.Long)
+		case CSVAcc:
+			if p.Acc < NoAccuracy {
+				v = fmtFloat(p.Acc)
+			}
+		case CSVEle:
+			if p.Ele.Valid {
+				v = fmtFloat(p.Ele.Float64)
+			}
+		case CSVVAcc:
+			if p.Ele.Valid && p.Ele.Acc < NoAccuracy {
+				v = fmtFloat(p.Ele.Acc)
+			}
+		}
+		x.rec[i] = v
+	}
+
+	return x.w.Write(x.rec)
+}
+
+func (x *csvWriter) Close() error {
+	if err := x.start(); err != nil {
+		return err
+	}
+	x.w.Flush()
+	return x.w.Error()
+}
+
+func formatCSVTime(t time.Time, layout string) string {
+	switch layout {
+	case "":
+		return t.UTC().Format(time.RFC3339Nano)
+	case CSVUnix:
+		return fmtFloat(float64(t.UnixNano()/1e6) / 1e3)
+	case CSVUnixMilli:
+		return strconv.FormatInt(t.UnixNano()/1e6, 10)
+	}
+	return t.UTC().Format(layout)
+}
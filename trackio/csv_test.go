@@ -0,0 +1,113 @@
+package trackio_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tajtiattila/track/trackio"
+)
+
+var sampleCSV = `Latitude,Longitude,Time,Altitude,Accuracy,Note
+47.5,19.05,2018-01-01T10:00:00Z,120,5,start
+47.5001,19.0501,2018-01-01 10:00:05,,,
+47.5002,19.0502,1514800810,,,
+x,19.0503,1514800815000,,,bad
+47.5004,19.0504,1514800820000,,,
+`
+
+func TestCSV(t *testing.T) {
+	if f, ok := trackio.DetectFormat([]byte(sampleCSV)); !ok || f != "csv" {
+		t.Fatalf("detected format %q", f)
+	}
+
+	d := trackio.NewDecoder(strings.NewReader(sampleCSV))
+	d.Accuracy = trackio.NoAccuracy
+	var errs []*trackio.DecodeError
+	d.HandleDecodeError = func(e *trackio.DecodeError) error {
+		errs = append(errs, e)
+		return nil
+	}
+	trk, err := d.Track()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(errs) != 1 {
+		t.Fatalf("got %d decode errors, want 1", len(errs))
+	}
+	badOffset := int64(strings.Index(sampleCSV, "x,"))
+	if e := errs[0]; e.Offset != badOffset || e.Line != 5 || e.Column != 1 || e.Record != 3 {
+		t.Errorf("got error position %q, want offset %d, line 5, column 1, record 3",
+			e.Position(), badOffset)
+	}
+
+	const wantLen = 4
+	if len(trk) != wantLen {
+		t.Fatalf("track length mismatch: want %d got %d", wantLen, len(trk))
+	}
+
+	epoch := time.Date(2018, 1, 1, 10, 0, 0, 0, time.UTC)
+	pointEqual(t, trk[0], trackio.Pt(epoch, 47.5, 19.05))
+	pointEqual(t, trk[1], trackio.Pt(epoch.Add(5*time.Second), 47.5001, 19.0501))
+	pointEqual(t, trk[2], trackio.Pt(epoch.Add(10*time.Second), 47.5002, 19.0502))
+	pointEqual(t, trk[3], trackio.Pt(epoch.Add(20*time.Second), 47.5004, 19.0504))
+
+	if p := trk[0]; p.Acc != 5 || !p.Ele.Valid || p.Ele.Float64 != 120 {
+		t.Errorf("got accuracy %v elevation %+v, want 5 and 120", p.Acc, p.Ele)
+	}
+}
+
+func TestCSVConfig(t *testing.T) {
+	epoch := time.Date(2018, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		what string
+		c    trackio.CSV
+		src  string
+	}{
+		{
+			"names",
+			trackio.CSV{
+				Names:      map[string]string{"Zeit": trackio.CSVTime, "Breite": trackio.CSVLat, "Länge": trackio.CSVLong},
+				TimeLayout: "02.01.2006 15:04:05",
+			},
+			"Zeit;Breite;Länge\n01.01.2018 10:00:00;47.5;19.05\n01.01.2018 10:00:05;47.5001;19.0501\n",
+		},
+		{
+			"columns",
+			trackio.CSV{
+				Columns:    []string{"", trackio.CSVTime, trackio.CSVLong, trackio.CSVLat},
+				TimeLayout: trackio.CSVUnixMilli,
+				Comma:      '\t',
+			},
+			"a\t1514800800000\t19.05\t47.5\nb\t1514800805000\t19.0501\t47.5001\n",
+		},
+	}
+
+	for _, tt := range tests {
+		pr, err := tt.c.NewPointReader(strings.NewReader(tt.src))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.what, err)
+		}
+
+		var trk trackio.Track
+		for {
+			p, err := pr.ReadPoint()
+			if err != nil {
+				if err != io.EOF {
+					t.Fatalf("%s: %v", tt.what, err)
+				}
+				break
+			}
+			trk = append(trk, p)
+		}
+
+		if len(trk) != 2 {
+			t.Fatalf("%s: track length mismatch: want 2 got %d", tt.what, len(trk))
+		}
+		pointEqual(t, trk[0], trackio.Pt(epoch, 47.5, 19.05))
+		pointEqual(t, trk[1], trackio.Pt(epoch.Add(5*time.Second), 47.5001, 19.0501))
+	}
+}
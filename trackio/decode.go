@@ -1,30 +1,78 @@
-// Package trackio is a simple GPS track decoder.
+// Package trackio is a simple GPS track decoder and encoder.
 //
-// GPX, KML and Google location history JSON formats are supported
-// by this package.
+// GPX, TCX, FIT, IGC, NMEA 0183, KML, GeoJSON (including GPSLogger), CSV,
+// Google location history JSON (including Records.json),
+// Google Semantic Location History, Google Timeline JSON,
+// OwnTracks Recorder and Overland formats, and the binary format
+// of track.Track are supported by this package.
+// Geotagged JPEG and HEIC photos may be decoded with NewPhotoDecoder.
 //
-// Additional formats may be registered with RegisterFormat.
+// Additional formats may be registered with RegisterFormat
+// and RegisterEncoder, or in a separate Registry.
+// NewDecoderFormat decodes input in a named format
+// without detection.
 package trackio
 
 import (
-	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"strings"
 )
 
 // ErrFormat indicates that decoding encountered an unknown format.
 var ErrFormat = errors.New("trackio: unknown format")
 
 // DecodeError indicates that a point within a track file is invalid.
+//
+// Decoder sets the position of the invalid record
+// if the format of the track file supports it.
 type DecodeError struct {
 	Reason error
+
+	// Offset is the byte offset of the invalid record
+	// within the uncompressed input, or -1 if unknown.
+	Offset int64
+
+	// Line and Column are the 1-based line and column of the
+	// invalid record in text formats, or zero if unknown.
+	Line, Column int
+
+	// Record is the index of the invalid record counting
+	// track points and decode errors, or -1 if unknown.
+	Record int
 }
 
 func (e *DecodeError) Error() string { return "trackio: " + e.Reason.Error() }
 
+// Position returns the known position information of e,
+// such as "offset 1234, line 56, column 7, record 89".
+//
+// It returns the empty string if the position is unknown.
+func (e *DecodeError) Position() string {
+	var v []string
+	if e.Offset >= 0 {
+		v = append(v, fmt.Sprintf("offset %d", e.Offset))
+	}
+	if e.Line > 0 {
+		v = append(v, fmt.Sprintf("line %d", e.Line))
+		if e.Column > 0 {
+			v = append(v, fmt.Sprintf("column %d", e.Column))
+		}
+	}
+	if e.Record >= 0 {
+		v = append(v, fmt.Sprintf("record %d", e.Record))
+	}
+	return strings.Join(v, ", ")
+}
+
+func newDecodeError(err error) *DecodeError {
+	return &DecodeError{Reason: err, Offset: -1, Record: -1}
+}
+
 func decodeError(format string, a ...interface{}) error {
-	return &DecodeError{fmt.Errorf(format, a...)}
+	return newDecodeError(fmt.Errorf(format, a...))
 }
 
 // DetectFormat determines if data represents a track file.
@@ -35,17 +83,7 @@ func decodeError(format string, a ...interface{}) error {
 // first data element (such as the document node in XML).
 // Typically the first few kilobytes is sufficient.
 func DetectFormat(data []byte) (string, bool) {
-	f, ok := detectFormat(data)
-	return f.name, ok
-}
-
-func detectFormat(data []byte) (format, bool) {
-	for _, f := range formats {
-		if f.detect(data) {
-			return f, true
-		}
-	}
-	return format{}, false
+	return DefaultRegistry.DetectFormat(data)
 }
 
 // DefaultAccuracy is the default accuracy set by NewDecoder.
@@ -76,28 +114,47 @@ type Decoder struct {
 	// track point decoding errors are ignored.
 	HandleDecodeError func(*DecodeError) error
 
+	// ReportProgress, if not nil, is called periodically
+	// by Decoder.Points and Decoder.Track.
+	ReportProgress func(Progress)
+
 	hasAccuracy bool // has point with Acc < NoAccuracy
+
+	nrec int // number of records read
+
+	input    countReader // input stream, if any
+	progress Progress
 }
 
 // NewDecoder returns a new decoder that reads from r
 // with Accuracy set to DefaultAccuracy.
+//
+// Gzip and bzip2 compressed input is uncompressed before decoding.
+// If r is a zip archive (such as a KMZ file), the first file
+// having a known format is decoded, using doc.kml if it exists.
 func NewDecoder(r io.Reader) *Decoder {
-	buf := new(bytes.Buffer)
-	_, err := io.Copy(buf, io.LimitReader(r, 64<<10))
-	if err != nil {
-		return newErrDecoder(err)
-	}
-
-	f, ok := detectFormat(buf.Bytes())
-	if !ok {
-		return newErrDecoder(ErrFormat)
-	}
+	return DefaultRegistry.NewDecoder(r)
+}
 
-	pr, err := f.newpr(io.MultiReader(buf, r))
-	if err != nil {
-		return newErrDecoder(err)
-	}
+// NewDecoderFormat returns a new decoder that reads from r
+// using the format registered with name, without detecting the format
+// of r. It is useful for inputs that can't be detected reliably,
+// such as CSV files without a header row.
+//
+// Compressed input is handled as with NewDecoder.
+// Reading from the Decoder returns ErrFormat
+// if no format is registered with name,
+// or if r is clearly not in that format.
+func NewDecoderFormat(r io.Reader, name string) *Decoder {
+	return DefaultRegistry.NewDecoderFormat(r, name)
+}
 
+// NewPointDecoder returns a new decoder that reads points from pr
+// with Accuracy set to DefaultAccuracy.
+//
+// It is useful for point sources other than track files,
+// such as databases.
+func NewPointDecoder(pr PointReader) *Decoder {
 	return newDecoder(pr)
 }
 
@@ -135,24 +192,32 @@ func (d *Decoder) Point() (pt Point, reset bool, err error) {
 			if err == io.EOF {
 				return Point{}, false, err
 			}
+			d.setPosition(err)
 			err = d.handleError(err)
 			if err == nil {
+				d.progress.Errors++
 				continue
 			}
 			return Point{}, false, err
 		}
 
+		d.nrec++
+
 		// first point with valid accuracy value
 		if !d.hasAccuracy && pt.Acc < NoAccuracy {
 			d.hasAccuracy = true
 			if d.Accuracy < NoAccuracy {
 				reset = true
+				d.progress.Rejected += d.progress.Accepted
+				d.progress.Accepted = 0
 			}
 		}
 
 		if !d.hasAccuracy || pt.Acc <= d.Accuracy {
+			d.progress.Accepted++
 			return pt, reset, err
 		}
+		d.progress.Rejected++
 	}
 	panic("unreachable")
 }
@@ -165,24 +230,25 @@ func (d *Decoder) Point() (pt Point, reset bool, err error) {
 // If there is no track point with a valid accuracy value,
 // all points are returned.
 func (d *Decoder) Track() (Track, error) {
-	var trk Track
-	for {
-		p, reset, err := d.Point()
-		if err != nil {
-			if err == io.EOF {
-				err = nil
-			}
-			trk.Sort()
-			return trk, err
-		}
+	return d.TrackContext(context.Background())
+}
 
-		if reset {
-			trk = trk[:0]
-		}
+// setPosition sets the position of err if it is a *DecodeError.
+func (d *Decoder) setPosition(err error) {
+	de, ok := err.(*DecodeError)
+	if !ok {
+		return
+	}
 
-		trk = append(trk, p)
+	if de.Record < 0 {
+		de.Record = d.nrec
+	}
+	d.nrec++
+
+	if ip, ok := d.PointReader.(inputPositioner); ok && de.Offset < 0 && de.Line == 0 {
+		p := ip.inputPos()
+		de.Offset, de.Line, de.Column = p.offset, p.line, p.column
 	}
-	panic("unreachable")
 }
 
 func (d *Decoder) handleError(err error) error {
@@ -214,25 +280,24 @@ type DetectFunc func(p []byte) bool
 // NewPointReader creates a new PointReader reading from r.
 type NewPointReader func(r io.Reader) (PointReader, error)
 
-// RegisterFormat registers a new track file format.
+// RegisterFormat registers a new track file format in DefaultRegistry.
+//
+// It panics if a format with name is already registered.
 func RegisterFormat(
 	name string,
 	detect DetectFunc,
 	newPointReader NewPointReader,
 ) {
-
-	for _, f := range formats {
-		if f.name == name {
-			panic("name already registered")
-		}
+	if err := DefaultRegistry.RegisterFormat(name, detect, newPointReader); err != nil {
+		panic(err)
 	}
-	formats = append(formats, format{name, detect, newPointReader})
 }
 
-type format struct {
-	name   string
-	detect DetectFunc
-	newpr  NewPointReader
+// RegisterDir registers a new directory format in DefaultRegistry.
+//
+// It panics if a directory format with name is already registered.
+func RegisterDir(name string, open OpenDirFunc) {
+	if err := DefaultRegistry.RegisterDir(name, open); err != nil {
+		panic(err)
+	}
 }
-
-var formats []format
@@ -0,0 +1,100 @@
+package trackio
+
+import (
+	"io"
+	"strconv"
+)
+
+// Encoder encodes tracks to an output stream.
+type Encoder struct {
+	// PointWriter used to encode Tracks.
+	//
+	// Clients may use PointWriter directly
+	// to encode individual track points.
+	PointWriter
+}
+
+// NewEncoder returns a new encoder that writes to w
+// using the encoder registered with the name format.
+//
+// Writing to the Encoder returns ErrFormat
+// if no encoder is registered with format.
+func NewEncoder(w io.Writer, format string) *Encoder {
+	return DefaultRegistry.NewEncoder(w, format)
+}
+
+// Encode writes the track points of trk to the output stream
+// using the underlying PointWriter, and closes it.
+func (e *Encoder) Encode(trk Track) error {
+	for _, p := range trk {
+		if err := e.WritePoint(p); err != nil {
+			return err
+		}
+	}
+	return e.Close()
+}
+
+type errPointWriter struct {
+	err error
+}
+
+func (w *errPointWriter) WritePoint(p Point) error { return w.err }
+func (w *errPointWriter) Close() error             { return w.err }
+
+// PointWriter writes track points to the underlying output stream.
+type PointWriter interface {
+	// WritePoint writes p to the output stream.
+	WritePoint(p Point) error
+
+	// Close writes the trailing data of the format, if any.
+	// It does not close the underlying output stream.
+	Close() error
+}
+
+// NewPointWriter creates a new PointWriter writing to w.
+type NewPointWriter func(w io.Writer) PointWriter
+
+// RegisterEncoder registers a new track file encoder in DefaultRegistry.
+//
+// It panics if an encoder with name is already registered.
+func RegisterEncoder(name string, newPointWriter NewPointWriter) {
+	if err := DefaultRegistry.RegisterEncoder(name, newPointWriter); err != nil {
+		panic(err)
+	}
+}
+
+// errWriter is an io.Writer that keeps the first error
+// returned by the underlying writer.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func newErrWriter(w io.Writer) *errWriter {
+	return &errWriter{w: w}
+}
+
+func (w *errWriter) Err() error { return w.err }
+
+func (w *errWriter) Write(p []byte) (n int, err error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	n, err = w.w.Write(p)
+	w.err = err
+	return n, err
+}
+
+func (w *errWriter) WriteString(s string) error {
+	if w.err != nil {
+		return w.err
+	}
+	_, err := w.Write([]byte(s))
+	return err
+}
+
+// fmtFloat formats v using the smallest number of digits
+// necessary to represent it exactly.
+func fmtFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,193 @@
+package trackio_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/tajtiattila/track/trackio"
+)
+
+func sampleEncodeTrack() trackio.Track {
+	epoch := time.Date(2018, 6, 1, 10, 30, 0, 0, time.UTC)
+
+	var trk trackio.Track
+	for i := 0; i < 10; i++ {
+		p := trackio.Pt(
+			epoch.Add(time.Duration(i)*1500*time.Millisecond),
+			47.5+float64(i)*1e-5,
+			19.0-float64(i)*1e-5,
+		)
+		if i%3 != 0 {
+			p.Acc = float64(5 * i)
+		}
+		if i%2 == 0 {
+			p.Ele = trackio.Elevation{
+				Valid:   true,
+				Float64: 100 + float64(i),
+				Acc:     10,
+			}
+		}
+		trk = append(trk, p)
+	}
+	return trk
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	tests := []struct {
+		format string
+		acc    bool // format has accuracy info
+		noacc  bool // format stores unknown accuracy values
+	}{
+		{"gpx", true, false},
+		{"kml", false, false},
+		{"googlejson", true, true},
+		{"geojson", true, true},
+		{"csv", true, true},
+		{"trak", false, false},
+	}
+
+	for _, tt := range tests {
+		src := sampleEncodeTrack()
+
+		buf := new(bytes.Buffer)
+		if err := trackio.NewEncoder(buf, tt.format).Encode(src); err != nil {
+			t.Fatalf("%s: encode: %v", tt.format, err)
+		}
+
+		if f, ok := trackio.DetectFormat(buf.Bytes()); !ok || f != tt.format {
+			t.Fatalf("%s: detected format %q", tt.format, f)
+		}
+
+		d := trackio.NewDecoder(buf)
+		d.Accuracy = trackio.NoAccuracy
+		got, err := d.Track()
+		if err != nil {
+			t.Fatalf("%s: decode: %v", tt.format, err)
+		}
+
+		if len(got) != len(src) {
+			t.Fatalf("%s: got %d points, want %d", tt.format, len(got), len(src))
+		}
+
+		for i := range src {
+			g, w := got[i], src[i]
+			pointEqual(t, g, w)
+
+			if tt.acc && (tt.noacc || w.Acc < trackio.NoAccuracy) && g.Acc != w.Acc {
+				t.Errorf("%s: point %d got accuracy %v, want %v", tt.format, i, g.Acc, w.Acc)
+			}
+
+			if w.Ele.Valid {
+				if !g.Ele.Valid || g.Ele.Float64 != w.Ele.Float64 {
+					t.Errorf("%s: point %d got elevation %+v, want %+v", tt.format, i, g.Ele, w.Ele)
+				}
+				if tt.acc && g.Ele.Acc != w.Ele.Acc {
+					t.Errorf("%s: point %d got vertical accuracy %v, want %v", tt.format, i, g.Ele.Acc, w.Ele.Acc)
+				}
+			} else if g.Ele.Valid {
+				t.Errorf("%s: point %d got elevation %+v, want none", tt.format, i, g.Ele)
+			}
+		}
+	}
+}
+
+func TestEncodeSegments(t *testing.T) {
+	src := sampleEncodeTrack()
+	for i := range src {
+		src[i].Trk = i / 6
+		src[i].Seg = i / 3
+	}
+
+	for _, format := range []string{"gpx", "kml", "geojson"} {
+		buf := new(bytes.Buffer)
+		if err := trackio.NewEncoder(buf, format).Encode(src); err != nil {
+			t.Fatalf("%s: encode: %v", format, err)
+		}
+
+		d := trackio.NewDecoder(buf)
+		d.Accuracy = trackio.NoAccuracy
+		got, err := d.Track()
+		if err != nil {
+			t.Fatalf("%s: decode: %v", format, err)
+		}
+
+		if len(got) != len(src) {
+			t.Fatalf("%s: got %d points, want %d", format, len(got), len(src))
+		}
+
+		for i := range src {
+			if got.SegmentStart(i) != src.SegmentStart(i) {
+				t.Errorf("%s: point %d segment start mismatch", format, i)
+			}
+		}
+	}
+}
+
+func TestEncodeMetadata(t *testing.T) {
+	src := sampleEncodeTrack()
+	for i := range src {
+		src[i].Trk = i / 5
+		src[i].Seg = i / 5
+	}
+
+	md := trackio.Metadata{
+		Name:        "Sample",
+		Description: "Sample & <test>",
+		Creator:     "encode_test",
+		Device:      "1234",
+		Tracks: []trackio.TrackInfo{
+			{Name: "First", Description: "First track"},
+			{Name: "Second"},
+		},
+	}
+
+	tests := []struct {
+		format string
+		want   trackio.Metadata
+	}{
+		{"gpx", trackio.Metadata{
+			Name:        md.Name,
+			Description: md.Description,
+			Creator:     md.Creator,
+			Tracks:      md.Tracks,
+		}},
+		{"kml", trackio.Metadata{
+			Name:        md.Name,
+			Description: md.Description,
+			Tracks:      md.Tracks,
+		}},
+		{"geojson", trackio.Metadata{
+			Tracks: md.Tracks[:1],
+		}},
+		{"googlejson", trackio.Metadata{
+			Device: md.Device,
+		}},
+	}
+
+	for _, tt := range tests {
+		buf := new(bytes.Buffer)
+		e := trackio.NewEncoder(buf, tt.format)
+		e.SetMetadata(md)
+		if err := e.Encode(src); err != nil {
+			t.Fatalf("%s: encode: %v", tt.format, err)
+		}
+
+		d := trackio.NewDecoder(buf)
+		if _, err := d.Track(); err != nil {
+			t.Fatalf("%s: decode: %v", tt.format, err)
+		}
+
+		if got := d.Metadata(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got metadata %+v, want %+v", tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestEncodeUnknownFormat(t *testing.T) {
+	err := trackio.NewEncoder(new(bytes.Buffer), "unknown").Encode(nil)
+	if err != trackio.ErrFormat {
+		t.Fatalf("got %v, want %v", err, trackio.ErrFormat)
+	}
+}
@@ -0,0 +1,536 @@
+package trackio
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+// exifGPS holds the location and time information
+// decoded from EXIF data.
+type exifGPS struct {
+	lat, long float64
+	hasPos    bool
+
+	ele    float64
+	hasEle bool
+
+	acc float64 // GPSHPositioningError, or NoAccuracy
+
+	gpsDate string        // GPSDateStamp, such as "2018:01:01"
+	gpsTime time.Duration // GPSTimeStamp (UTC time of day)
+	hasTime bool
+
+	original string // DateTimeOriginal, such as "2018:01:01 10:00:00"
+	offset   string // OffsetTimeOriginal, such as "+01:00"
+}
+
+var errNoExif = errors.New("no exif data")
+
+// EXIF tags
+const (
+	exifIFDPointer = 0x8769
+	exifGPSPointer = 0x8825
+
+	exifDateTimeOriginal   = 0x9003
+	exifOffsetTimeOriginal = 0x9011
+
+	exifGPSLatitudeRef  = 0x01
+	exifGPSLatitude     = 0x02
+	exifGPSLongitudeRef = 0x03
+	exifGPSLongitude    = 0x04
+	exifGPSAltitudeRef  = 0x05
+	exifGPSAltitude     = 0x06
+	exifGPSTimeStamp    = 0x07
+	exifGPSDateStamp    = 0x1d
+	exifGPSHPosError    = 0x1f
+)
+
+// time returns the time of the photo.
+//
+// The GPS date and time stamps are used if present.
+// If the GPS date is missing, it is taken from DateTimeOriginal
+// assuming local time is within 14 hours of UTC.
+// Otherwise DateTimeOriginal is used with OffsetTimeOriginal,
+// or in the local time zone if the offset is missing.
+func (x *exifGPS) time() (time.Time, error) {
+	const layout = "2006:01:02 15:04:05"
+
+	var orig time.Time
+	var hasOrig bool
+	if s := strings.TrimSpace(x.original); s != "" {
+		var err error
+		if x.offset != "" {
+			orig, err = time.Parse(layout+"-07:00", s+x.offset)
+		} else {
+			orig, err = time.ParseInLocation(layout, s, time.Local)
+		}
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid DateTimeOriginal %q", s)
+		}
+		hasOrig = true
+	}
+
+	if x.hasTime {
+		if x.gpsDate != "" {
+			d, err := time.Parse("2006:01:02", strings.TrimSpace(x.gpsDate))
+			if err != nil {
+				return time.Time{}, fmt.Errorf("invalid GPSDateStamp %q", x.gpsDate)
+			}
+			return d.Add(x.gpsTime), nil
+		}
+		if hasOrig {
+			y, m, d := orig.Date()
+			t := time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Add(x.gpsTime)
+			// naive local time of orig as if it were UTC
+			lt := time.Date(y, m, d, orig.Hour(), orig.Minute(), orig.Second(), 0, time.UTC)
+			switch dt := t.Sub(lt); {
+			case dt > 14*time.Hour:
+				t = t.AddDate(0, 0, -1)
+			case dt < -14*time.Hour:
+				t = t.AddDate(0, 0, 1)
+			}
+			return t, nil
+		}
+	}
+
+	if hasOrig {
+		return orig.UTC(), nil
+	}
+	return time.Time{}, errors.New("no time information")
+}
+
+// readJPEGExif returns the EXIF data (TIFF header and IFDs) from a JPEG file.
+func readJPEGExif(r io.Reader) ([]byte, error) {
+	br := bufio.NewReader(r)
+
+	var soi [2]byte
+	if _, err := io.ReadFull(br, soi[:]); err != nil || soi != [2]byte{0xff, 0xd8} {
+		return nil, errors.New("not a jpeg file")
+	}
+
+	for {
+		var m [4]byte
+		if _, err := io.ReadFull(br, m[:2]); err != nil {
+			return nil, err
+		}
+		if m[0] != 0xff {
+			return nil, errors.New("invalid jpeg marker")
+		}
+		if m[1] == 0xff {
+			// fill byte
+			br.UnreadByte()
+			continue
+		}
+		if m[1] == 0xd9 || m[1] == 0xda {
+			// end of image or start of scan
+			return nil, errNoExif
+		}
+		if 0xd0 <= m[1] && m[1] <= 0xd7 || m[1] == 0x01 {
+			// markers without length
+			continue
+		}
+
+		if _, err := io.ReadFull(br, m[2:]); err != nil {
+			return nil, err
+		}
+		n := int(binary.BigEndian.Uint16(m[2:])) - 2
+		if n < 0 {
+			return nil, errors.New("invalid jpeg segment length")
+		}
+
+		if m[1] != 0xe1 {
+			if _, err := br.Discard(n); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		seg := make([]byte, n)
+		if _, err := io.ReadFull(br, seg); err != nil {
+			return nil, err
+		}
+		if bytes.HasPrefix(seg, []byte("Exif\x00\x00")) {
+			return seg[6:], nil
+		}
+		// other APP1 segment such as XMP
+	}
+}
+
+// heifExif returns the EXIF data (TIFF header and IFDs) of a HEIF file.
+func heifExif(p []byte) ([]byte, error) {
+	meta, ok := isobmffBox(p, "meta")
+	if !ok || len(meta) < 4 {
+		return nil, errNoExif
+	}
+	meta = meta[4:] // version and flags
+
+	iinf, ok0 := isobmffBox(meta, "iinf")
+	iloc, ok1 := isobmffBox(meta, "iloc")
+	if !ok0 || !ok1 {
+		return nil, errNoExif
+	}
+
+	id, ok := heifExifItem(iinf)
+	if !ok {
+		return nil, errNoExif
+	}
+
+	off, n, ok := heifItemLocation(iloc, id)
+	if !ok || off > uint64(len(p)) || n > uint64(len(p))-off || n < 4 {
+		return nil, errors.New("invalid heif exif location")
+	}
+	data := p[off : off+n]
+
+	// skip exif_tiff_header_offset and the header
+	skip := uint64(binary.BigEndian.Uint32(data)) + 4
+	if skip > uint64(len(data)) {
+		return nil, errors.New("invalid heif exif header offset")
+	}
+	return data[skip:], nil
+}
+
+// isobmffBox returns the contents of the first box with type typ in p.
+func isobmffBox(p []byte, typ string) ([]byte, bool) {
+	for {
+		t, body, rest, ok := isobmffNext(p)
+		if !ok {
+			return nil, false
+		}
+		if t == typ {
+			return body, true
+		}
+		p = rest
+	}
+}
+
+// isobmffNext returns the type and contents of the first box in p,
+// and the bytes following it.
+func isobmffNext(p []byte) (typ string, body, rest []byte, ok bool) {
+	if len(p) < 8 {
+		return "", nil, nil, false
+	}
+	size := uint64(binary.BigEndian.Uint32(p))
+	hdr := uint64(8)
+	switch size {
+	case 0:
+		size = uint64(len(p))
+	case 1:
+		if len(p) < 16 {
+			return "", nil, nil, false
+		}
+		size, hdr = binary.BigEndian.Uint64(p[8:]), 16
+	}
+	if size < hdr || size > uint64(len(p)) {
+		return "", nil, nil, false
+	}
+	return string(p[4:8]), p[hdr:size], p[size:], true
+}
+
+// heifExifItem returns the item ID of the Exif item in the iinf box.
+func heifExifItem(iinf []byte) (uint32, bool) {
+	if len(iinf) < 6 {
+		return 0, false
+	}
+	p := iinf[6:]
+	if iinf[0] != 0 {
+		// 32 bit entry count
+		if len(iinf) < 8 {
+			return 0, false
+		}
+		p = iinf[8:]
+	}
+
+	for {
+		typ, infe, rest, ok := isobmffNext(p)
+		if !ok {
+			return 0, false
+		}
+		p = rest
+		if typ != "infe" || len(infe) < 4 {
+			continue
+		}
+
+		var id uint32
+		var itemType []byte
+		switch v := infe[0]; {
+		case v == 2 && len(infe) >= 12:
+			id, itemType = uint32(binary.BigEndian.Uint16(infe[4:])), infe[8:12]
+		case v == 3 && len(infe) >= 14:
+			id, itemType = binary.BigEndian.Uint32(infe[4:]), infe[10:14]
+		}
+		if string(itemType) == "Exif" {
+			return id, true
+		}
+	}
+}
+
+// heifItemLocation returns the file offset and length
+// of item id from the iloc box.
+func heifItemLocation(iloc []byte, id uint32) (off, n uint64, ok bool) {
+	r := &byteReader{p: iloc}
+	v := r.uint(1)
+	r.uint(3) // flags
+	sizes := r.uint(1)
+	offSize, lenSize := int(sizes>>4), int(sizes&15)
+	sizes = r.uint(1)
+	baseSize, idxSize := int(sizes>>4), int(sizes&15)
+	if v == 0 {
+		idxSize = 0
+	}
+
+	var count uint64
+	if v < 2 {
+		count = r.uint(2)
+	} else {
+		count = r.uint(4)
+	}
+
+	for i := uint64(0); i < count && r.err == nil; i++ {
+		var item uint64
+		if v < 2 {
+			item = r.uint(2)
+		} else {
+			item = r.uint(4)
+		}
+		method := uint64(0)
+		if v == 1 || v == 2 {
+			method = r.uint(2) & 15
+		}
+		r.uint(2) // data reference index
+		base := r.uint(baseSize)
+		extents := r.uint(2)
+		for j := uint64(0); j < extents; j++ {
+			r.uint(idxSize)
+			eoff := r.uint(offSize)
+			elen := r.uint(lenSize)
+			if j == 0 && uint32(item) == id && method == 0 && r.err == nil {
+				// only the first extent is used
+				return base + eoff, elen, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// byteReader reads big endian unsigned integers.
+type byteReader struct {
+	p   []byte
+	err error
+}
+
+func (r *byteReader) uint(n int) uint64 {
+	if n > len(r.p) {
+		r.err = io.ErrUnexpectedEOF
+		r.p = nil
+		return 0
+	}
+	var v uint64
+	for _, b := range r.p[:n] {
+		v = v<<8 | uint64(b)
+	}
+	r.p = r.p[n:]
+	return v
+}
+
+// decodeExif decodes location and time information
+// from EXIF data starting with the TIFF header.
+func decodeExif(p []byte) (*exifGPS, error) {
+	if len(p) < 8 {
+		return nil, errNoExif
+	}
+
+	var bo binary.ByteOrder
+	switch string(p[:4]) {
+	case "II*\x00":
+		bo = binary.LittleEndian
+	case "MM\x00*":
+		bo = binary.BigEndian
+	default:
+		return nil, errors.New("invalid tiff header")
+	}
+
+	t := &tiff{p: p, bo: bo}
+	x := &exifGPS{acc: NoAccuracy}
+
+	ifd0, err := t.ifd(bo.Uint32(p[4:]))
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range ifd0 {
+		switch e.tag {
+		case exifIFDPointer:
+			ifd, err := t.ifd(e.uint(t))
+			if err != nil {
+				return nil, err
+			}
+			for _, e := range ifd {
+				switch e.tag {
+				case exifDateTimeOriginal:
+					x.original = e.ascii(t)
+				case exifOffsetTimeOriginal:
+					x.offset = strings.TrimSpace(e.ascii(t))
+				}
+			}
+		case exifGPSPointer:
+			ifd, err := t.ifd(e.uint(t))
+			if err != nil {
+				return nil, err
+			}
+			x.gps(t, ifd)
+		}
+	}
+
+	return x, nil
+}
+
+// gps decodes the entries of the GPS IFD.
+func (x *exifGPS) gps(t *tiff, ifd []tiffEntry) {
+	var latRef, longRef string
+	var lat, long []float64
+	var altRef uint32
+	for _, e := range ifd {
+		switch e.tag {
+		case exifGPSLatitudeRef:
+			latRef = e.ascii(t)
+		case exifGPSLatitude:
+			lat = e.rationals(t)
+		case exifGPSLongitudeRef:
+			longRef = e.ascii(t)
+		case exifGPSLongitude:
+			long = e.rationals(t)
+		case exifGPSAltitudeRef:
+			altRef = e.uint(t)
+		case exifGPSAltitude:
+			if v := e.rationals(t); len(v) == 1 {
+				x.ele, x.hasEle = v[0], true
+			}
+		case exifGPSTimeStamp:
+			if v := e.rationals(t); len(v) == 3 {
+				s := v[0]*3600 + v[1]*60 + v[2]
+				x.gpsTime = time.Duration(math.Floor(s*1e3+0.5)) * time.Millisecond
+				x.hasTime = true
+			}
+		case exifGPSDateStamp:
+			x.gpsDate = e.ascii(t)
+		case exifGPSHPosError:
+			if v := e.rationals(t); len(v) == 1 && v[0] > 0 {
+				x.acc = v[0]
+			}
+		}
+	}
+
+	if altRef == 1 {
+		x.ele = -x.ele
+	}
+
+	if len(lat) == 3 && len(long) == 3 {
+		x.lat = lat[0] + lat[1]/60 + lat[2]/3600
+		x.long = long[0] + long[1]/60 + long[2]/3600
+		if latRef == "S" {
+			x.lat = -x.lat
+		}
+		if longRef == "W" {
+			x.long = -x.long
+		}
+		x.hasPos = true
+	}
+}
+
+type tiff struct {
+	p  []byte
+	bo binary.ByteOrder
+}
+
+type tiffEntry struct {
+	tag, typ uint16
+	count    uint32
+	value    []byte // value or offset (4 bytes)
+}
+
+// TIFF field types
+const (
+	tiffByte     = 1
+	tiffASCII    = 2
+	tiffShort    = 3
+	tiffLong     = 4
+	tiffRational = 5
+)
+
+// ifd returns the entries of the IFD at offset off.
+func (t *tiff) ifd(off uint32) ([]tiffEntry, error) {
+	if uint64(off)+2 > uint64(len(t.p)) {
+		return nil, errors.New("invalid ifd offset")
+	}
+	n := int(t.bo.Uint16(t.p[off:]))
+	p := t.p[off+2:]
+	if len(p) < n*12 {
+		return nil, errors.New("short ifd")
+	}
+	v := make([]tiffEntry, n)
+	for i := range v {
+		e := p[i*12:]
+		v[i] = tiffEntry{
+			tag:   t.bo.Uint16(e),
+			typ:   t.bo.Uint16(e[2:]),
+			count: t.bo.Uint32(e[4:]),
+			value: e[8:12],
+		}
+	}
+	return v, nil
+}
+
+// data returns the value bytes of e,
+// or nil if its offset is invalid.
+func (e *tiffEntry) data(t *tiff, size int) []byte {
+	n := uint64(e.count) * uint64(size)
+	if n <= 4 {
+		return e.value[:n]
+	}
+	off := uint64(t.bo.Uint32(e.value))
+	if off+n > uint64(len(t.p)) {
+		return nil
+	}
+	return t.p[off : off+n]
+}
+
+func (e *tiffEntry) uint(t *tiff) uint32 {
+	switch e.typ {
+	case tiffByte:
+		return uint32(e.value[0])
+	case tiffShort:
+		return uint32(t.bo.Uint16(e.value))
+	case tiffLong:
+		return t.bo.Uint32(e.value)
+	}
+	return 0
+}
+
+func (e *tiffEntry) ascii(t *tiff) string {
+	if e.typ != tiffASCII {
+		return ""
+	}
+	return strings.TrimRight(string(e.data(t, 1)), "\x00")
+}
+
+func (e *tiffEntry) rationals(t *tiff) []float64 {
+	if e.typ != tiffRational {
+		return nil
+	}
+	p := e.data(t, 8)
+	var v []float64
+	for ; len(p) >= 8; p = p[8:] {
+		num, den := t.bo.Uint32(p), t.bo.Uint32(p[4:])
+		if den == 0 {
+			return nil
+		}
+		v = append(v, float64(num)/float64(den))
+	}
+	return v
+}
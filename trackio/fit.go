@@ -0,0 +1,414 @@
+package trackio
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+func init() {
+	RegisterFormat("fit", isFIT, newFIT)
+}
+
+/* FIT Format:
+
+FIT is a binary format of the Garmin FIT SDK.
+
+A FIT file has a header of 12 or 14 bytes:
+
+	size           uint8  // header size, 12 or 14
+	protocol       uint8  // protocol version
+	profile        uint16 // profile version
+	dataSize       uint32 // size of data records
+	dataType       [4]byte // ".FIT"
+	crc            uint16 // header CRC (only when size is 14)
+
+followed by dataSize bytes of data records, and a file CRC (uint16).
+Multiple FIT files may be chained within a single stream.
+
+Data records are either definition or data messages.
+Definition messages define the layout of data messages
+for a local message type (0..15).
+Track points are stored in record messages (global message 20).
+
+see https://developer.garmin.com/fit/protocol/
+
+*/
+
+func isFIT(p []byte) bool {
+	return len(p) >= 12 && p[0] >= 12 && string(p[8:12]) == ".FIT"
+}
+
+func newFIT(r io.Reader) (PointReader, error) {
+	return &fit{r: bufio.NewReader(r)}, nil
+}
+
+// FIT global message numbers
+const (
+	fitMsgRecord = 20
+)
+
+// FIT record message field numbers
+const (
+	fitFieldTimestamp        = 253
+	fitFieldPositionLat      = 0
+	fitFieldPositionLong     = 1
+	fitFieldAltitude         = 2
+	fitFieldHeartRate        = 3
+	fitFieldCadence          = 4
+	fitFieldGPSAccuracy      = 31
+	fitFieldEnhancedAltitude = 78
+)
+
+// fitEpoch is the zero value of FIT timestamps.
+var fitEpoch = time.Date(1989, 12, 31, 0, 0, 0, 0, time.UTC)
+
+// fitMinTimestamp is the smallest timestamp value
+// representing a date after fitEpoch.
+// Values below this are relative to an unknown time.
+const fitMinTimestamp = 0x10000000
+
+type fit struct {
+	r *bufio.Reader
+
+	started bool
+	n       int64 // bytes of data records left in the current file
+	file    int   // index of the current file, starting at 1
+
+	defs [16]*fitDef
+	ts   uint32 // last timestamp seen
+
+	buf []byte
+}
+
+type fitDef struct {
+	global uint16
+	order  binary.ByteOrder
+	fields []fitField
+
+	size    int // total size of fields
+	devSize int // total size of developer fields
+}
+
+type fitField struct {
+	num, size, base byte
+}
+
+func (f *fit) ReadPoint() (Point, error) {
+	for {
+		if f.n == 0 {
+			if err := f.nextFile(); err != nil {
+				return Point{}, err
+			}
+			continue
+		}
+
+		b, err := f.read(1)
+		if err != nil {
+			return Point{}, err
+		}
+
+		h := b[0]
+		if h&0x80 != 0 {
+			// compressed timestamp header
+			local := (h >> 5) & 3
+			ofs := uint32(h & 0x1f)
+			ts := f.ts&^0x1f + ofs
+			if ofs < f.ts&0x1f {
+				ts += 0x20
+			}
+			f.ts = ts
+			if pt, ok, err := f.readData(local, true); ok || err != nil {
+				return pt, err
+			}
+			continue
+		}
+
+		local := h & 0x0f
+		if h&0x40 != 0 {
+			if err := f.readDef(local, h&0x20 != 0); err != nil {
+				return Point{}, err
+			}
+			continue
+		}
+
+		if pt, ok, err := f.readData(local, false); ok || err != nil {
+			return pt, err
+		}
+	}
+}
+
+// nextFile reads the CRC of the current file (if any)
+// and the header of the next FIT file in the stream.
+func (f *fit) nextFile() error {
+	if f.started {
+		if _, err := io.ReadFull(f.r, make([]byte, 2)); err != nil {
+			return unexpectedEOF(err)
+		}
+	}
+
+	hsize, err := f.r.ReadByte()
+	if err != nil {
+		// io.EOF at the end of the last file
+		return err
+	}
+	f.started = true
+
+	if hsize < 12 {
+		return fmt.Errorf("trackio: invalid fit header size %d", hsize)
+	}
+	h := make([]byte, hsize-1)
+	if _, err := io.ReadFull(f.r, h); err != nil {
+		return unexpectedEOF(err)
+	}
+	if string(h[7:11]) != ".FIT" {
+		return fmt.Errorf("trackio: invalid fit header")
+	}
+
+	f.n = int64(binary.LittleEndian.Uint32(h[3:7]))
+	f.defs = [16]*fitDef{}
+	f.file++
+	return nil
+}
+
+// read reads n bytes from the data records of the current file.
+//
+// The slice returned is valid until the next call to read.
+func (f *fit) read(n int) ([]byte, error) {
+	if int64(n) > f.n {
+		return nil, fmt.Errorf("trackio: fit record exceeds data size")
+	}
+	f.n -= int64(n)
+
+	if cap(f.buf) < n {
+		f.buf = make([]byte, n)
+	}
+	p := f.buf[:n]
+	if _, err := io.ReadFull(f.r, p); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	return p, nil
+}
+
+func (f *fit) readDef(local byte, dev bool) error {
+	p, err := f.read(5)
+	if err != nil {
+		return err
+	}
+
+	def := new(fitDef)
+	if p[1] == 0 {
+		def.order = binary.LittleEndian
+	} else {
+		def.order = binary.BigEndian
+	}
+	def.global = def.order.Uint16(p[2:4])
+
+	nfields := int(p[4])
+	if p, err = f.read(3 * nfields); err != nil {
+		return err
+	}
+	def.fields = make([]fitField, nfields)
+	for i := range def.fields {
+		x := p[3*i:]
+		def.fields[i] = fitField{num: x[0], size: x[1], base: x[2]}
+		def.size += int(x[1])
+	}
+
+	if dev {
+		p, err := f.read(1)
+		if err != nil {
+			return err
+		}
+		ndev := int(p[0])
+		if p, err = f.read(3 * ndev); err != nil {
+			return err
+		}
+		for i := 0; i < ndev; i++ {
+			def.devSize += int(p[3*i+1])
+		}
+	}
+
+	f.defs[local] = def
+	return nil
+}
+
+// readData reads a data message having local message type local.
+//
+// It returns ok == true if a track point was decoded.
+// The argument hasTs indicates if the record has a compressed timestamp header.
+func (f *fit) readData(local byte, hasTs bool) (pt Point, ok bool, err error) {
+	def := f.defs[local]
+	if def == nil {
+		return Point{}, false, fmt.Errorf("trackio: fit data message of undefined local message type %d", local)
+	}
+
+	p, err := f.read(def.size + def.devSize)
+	if err != nil {
+		return Point{}, false, err
+	}
+
+	var rec fitRecord
+	for _, fd := range def.fields {
+		v, valid := def.value(p[:fd.size], fd.base)
+		p = p[fd.size:]
+
+		if fd.num == fitFieldTimestamp {
+			if valid {
+				f.ts = uint32(v)
+				hasTs = true
+			}
+			continue
+		}
+
+		if def.global == fitMsgRecord {
+			if err := rec.set(fd, v, valid); err != nil {
+				return Point{}, false, err
+			}
+		}
+	}
+
+	if def.global != fitMsgRecord || !rec.hasPos() {
+		// message of no interest, or record without position
+		return Point{}, false, nil
+	}
+
+	if !hasTs || f.ts < fitMinTimestamp {
+		return Point{}, false, decodeError("fit record without timestamp")
+	}
+
+	// chained files are separate tracks
+	pt = rec.point(f.ts)
+	pt.Trk, pt.Seg = f.file-1, f.file-1
+	return pt, true, nil
+}
+
+// value returns the integer value of the field data p with base type bt.
+//
+// It returns valid == false if the value is invalid,
+// or if it is not an integer.
+func (d *fitDef) value(p []byte, bt byte) (v int64, valid bool) {
+	var u uint64
+	switch len(p) {
+	case 1:
+		u = uint64(p[0])
+	case 2:
+		u = uint64(d.order.Uint16(p))
+	case 4:
+		u = uint64(d.order.Uint32(p))
+	case 8:
+		u = d.order.Uint64(p)
+	default:
+		return 0, false
+	}
+
+	bits := uint(8 * len(p))
+	switch bt & 0x1f {
+	case 0x01, 0x03, 0x05, 0x0e: // sint8, sint16, sint32, sint64
+		if u == 1<<(bits-1)-1 {
+			return 0, false
+		}
+		// sign extend
+		return int64(u<<(64-bits)) >> (64 - bits), true
+	case 0x0a, 0x0b, 0x0c, 0x10: // uint8z, uint16z, uint32z, uint64z
+		return int64(u), u != 0
+	case 0x07, 0x08, 0x09: // string, float32, float64
+		return 0, false
+	}
+	return int64(u), u != uint64(math.MaxUint64)>>(64-bits)
+}
+
+// fitRecord holds the fields of a record message.
+type fitRecord struct {
+	lat, long       int64
+	hasLat, hasLong bool
+
+	alt    float64
+	hasAlt bool
+
+	acc float64
+
+	hr, cad       float64
+	hasHR, hasCad bool
+}
+
+func (r *fitRecord) set(fd fitField, v int64, valid bool) error {
+	switch fd.num {
+	case fitFieldPositionLat, fitFieldPositionLong:
+		if fd.size != 4 {
+			return decodeError("fit record position field %d has invalid size %d", fd.num, fd.size)
+		}
+	}
+	if !valid {
+		return nil
+	}
+	switch fd.num {
+	case fitFieldPositionLat:
+		r.lat, r.hasLat = v, true
+	case fitFieldPositionLong:
+		r.long, r.hasLong = v, true
+	case fitFieldAltitude:
+		if !r.hasAlt {
+			r.alt, r.hasAlt = fitAltitude(v), true
+		}
+	case fitFieldEnhancedAltitude:
+		r.alt, r.hasAlt = fitAltitude(v), true
+	case fitFieldGPSAccuracy:
+		r.acc = float64(v)
+	case fitFieldHeartRate:
+		r.hr, r.hasHR = float64(v), true
+	case fitFieldCadence:
+		r.cad, r.hasCad = float64(v), true
+	}
+	return nil
+}
+
+func (r *fitRecord) hasPos() bool {
+	return r.hasLat && r.hasLong
+}
+
+func (r *fitRecord) point(ts uint32) Point {
+	pt := Pt(fitEpoch.Add(time.Duration(ts)*time.Second),
+		semicircles(r.lat), semicircles(r.long))
+
+	if r.acc != 0 {
+		pt.Acc = r.acc
+	}
+
+	if r.hasAlt {
+		pt.Ele.Valid = true
+		pt.Ele.Float64 = r.alt
+	}
+
+	if r.hasHR || r.hasCad {
+		pt.Sensors = make(Sensors)
+		if r.hasHR {
+			pt.Sensors[HeartRate] = r.hr
+		}
+		if r.hasCad {
+			pt.Sensors[Cadence] = r.cad
+		}
+	}
+
+	return pt
+}
+
+// semicircles converts a FIT semicircle value to degrees.
+func semicircles(v int64) float64 {
+	return float64(v) * (180.0 / (1 << 31))
+}
+
+// fitAltitude converts a FIT altitude value to meters.
+func fitAltitude(v int64) float64 {
+	return float64(v)/5 - 500
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,154 @@
+package trackio_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/tajtiattila/track/trackio"
+)
+
+var fitEpoch = time.Date(1989, 12, 31, 0, 0, 0, 0, time.UTC)
+
+// fitBuilder builds FIT files for tests.
+type fitBuilder struct {
+	data bytes.Buffer
+}
+
+type fitTestField struct {
+	num, size, base byte
+}
+
+func (b *fitBuilder) def(local byte, order binary.ByteOrder, global uint16, fields ...fitTestField) {
+	b.data.WriteByte(0x40 | local)
+	b.data.WriteByte(0) // reserved
+	if order == binary.BigEndian {
+		b.data.WriteByte(1)
+	} else {
+		b.data.WriteByte(0)
+	}
+	binary.Write(&b.data, order, global)
+	b.data.WriteByte(byte(len(fields)))
+	for _, f := range fields {
+		b.data.Write([]byte{f.num, f.size, f.base})
+	}
+}
+
+func (b *fitBuilder) msg(hdr byte, order binary.ByteOrder, values ...interface{}) {
+	b.data.WriteByte(hdr)
+	for _, v := range values {
+		binary.Write(&b.data, order, v)
+	}
+}
+
+func (b *fitBuilder) bytes() []byte {
+	var h bytes.Buffer
+	h.WriteByte(14)
+	h.WriteByte(0x10)
+	binary.Write(&h, binary.LittleEndian, uint16(2093))
+	binary.Write(&h, binary.LittleEndian, uint32(b.data.Len()))
+	h.WriteString(".FIT")
+	h.Write([]byte{0, 0}) // header crc (unchecked)
+	h.Write(b.data.Bytes())
+	h.Write([]byte{0, 0}) // file crc (unchecked)
+	return h.Bytes()
+}
+
+func semicircles(deg float64) int32 {
+	return int32(math.Floor(deg*(1<<31)/180 + 0.5))
+}
+
+func TestFIT(t *testing.T) {
+	le, be := binary.LittleEndian, binary.BigEndian
+
+	ts0 := uint32(time.Date(2018, 5, 1, 10, 0, 0, 0, time.UTC).Sub(fitEpoch) / time.Second)
+
+	var b fitBuilder
+
+	// file_id message, to be skipped
+	b.def(0, le, 0, fitTestField{1, 2, 0x84}, fitTestField{4, 4, 0x86})
+	b.msg(0, le, uint16(1), ts0)
+
+	// record with timestamp, position, altitude, heart rate and accuracy
+	b.def(1, le, 20,
+		fitTestField{253, 4, 0x86},
+		fitTestField{0, 4, 0x85},
+		fitTestField{1, 4, 0x85},
+		fitTestField{2, 2, 0x84},
+		fitTestField{3, 1, 0x02},
+		fitTestField{31, 1, 0x02},
+	)
+	b.msg(1, le, ts0, semicircles(47.5), semicircles(19.05), uint16((120+500)*5), uint8(96), uint8(4))
+	// invalid position
+	b.msg(1, le, ts0+1, int32(math.MaxInt32), int32(math.MaxInt32), uint16(0xffff), uint8(0xff), uint8(0xff))
+
+	// big endian record without timestamp field
+	b.def(2, be, 20,
+		fitTestField{0, 4, 0x85},
+		fitTestField{1, 4, 0x85},
+		fitTestField{78, 4, 0x86},
+	)
+	// compressed timestamp header with local message type 2
+	b.msg(0x80|2<<5|byte((ts0+3)&0x1f), be, semicircles(-33.9), semicircles(151.2), uint32((10.4+500)*5))
+
+	// unknown message with developer fields, to be skipped
+	b.data.Write([]byte{0x60 | 3, 0, 0, 0xff, 0x00, 1, 1, 1, 0x02, 1, 0, 2, 0})
+	b.msg(3, le, uint8(1), uint16(7))
+
+	// record with an invalid position field size
+	b.def(4, le, 20, fitTestField{253, 4, 0x86}, fitTestField{0, 2, 0x83}, fitTestField{1, 4, 0x85})
+	b.msg(4, le, ts0+4, int16(0), semicircles(19))
+
+	d := trackio.NewDecoder(bytes.NewReader(b.bytes()))
+	d.Accuracy = trackio.NoAccuracy
+	var nerr int
+	d.HandleDecodeError = func(*trackio.DecodeError) error {
+		nerr++
+		return nil
+	}
+	trk, err := d.Track()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if nerr != 1 {
+		t.Errorf("got %d decode errors, want 1", nerr)
+	}
+
+	const wantLen = 2
+	if len(trk) != wantLen {
+		t.Fatalf("track length mismatch: want %d got %d", wantLen, len(trk))
+	}
+
+	const eps = 1e-7
+	near := func(a, b float64) bool { return math.Abs(a-b) < eps }
+
+	p := trk[0]
+	if !p.Time.Equal(time.Date(2018, 5, 1, 10, 0, 0, 0, time.UTC)) ||
+		!near(p.Lat, 47.5) || !near(p.Long, 19.05) {
+		t.Errorf("got point %v %v,%v", p.Time, p.Lat, p.Long)
+	}
+	if !p.Ele.Valid || p.Ele.Float64 != 120 {
+		t.Errorf("got elevation %+v, want 120", p.Ele)
+	}
+	if p.Acc != 4 {
+		t.Errorf("got accuracy %v, want 4", p.Acc)
+	}
+	if hr := p.Sensors[trackio.HeartRate]; hr != 96 {
+		t.Errorf("got heart rate %v, want 96", hr)
+	}
+
+	p = trk[1]
+	if !p.Time.Equal(time.Date(2018, 5, 1, 10, 0, 3, 0, time.UTC)) ||
+		!near(p.Lat, -33.9) || !near(p.Long, 151.2) {
+		t.Errorf("got point %v %v,%v", p.Time, p.Lat, p.Long)
+	}
+	if !p.Ele.Valid || !near(p.Ele.Float64, 10.4) {
+		t.Errorf("got elevation %+v, want 10.4", p.Ele)
+	}
+	if p.Acc != trackio.NoAccuracy {
+		t.Errorf("got accuracy %v, want none", p.Acc)
+	}
+}
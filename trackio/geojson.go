@@ -0,0 +1,520 @@
+package trackio
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+func init() {
+	RegisterFormat("geojson", isGeoJSON, newGeoJSON)
+	RegisterEncoder("geojson", newGeoJSONWriter)
+}
+
+/* GeoJSON Format:
+
+{"type": "FeatureCollection", "features": [ {
+    "type": "Feature",
+    "geometry": {
+      "type": "LineString",
+      "coordinates": [ [102.0, 0.0], [103.0, 1.0] ]
+    },
+    "properties": {
+      "times": [ "2018-01-01T10:00:00Z", "2018-01-01T10:00:05Z" ],
+      "accuracy": [ 5, null ],
+      "elevation": [ 120.5, 121 ]
+    }
+  }, {
+    "type": "Feature",
+    "geometry": { "type": "Point", "coordinates": [102.0, 0.5] },
+    "properties": { "time": "2018-01-01T10:00:07Z" }
+  }
+]}
+
+GPSLogger for Android writes Point features with properties:
+
+    "properties": {
+      "time": "2018-01-01T10:00:07.000Z", "time_long": 1514800807000,
+      "provider": "gps", "accuracy": 8, "altitude": 120,
+      "bearing": 270, "speed": 1.5, "battery": 87
+    }
+
+Track points are decoded from Point features having a "time" property,
+and LineString and MultiLineString features having a "times"
+or "coordTimes" property holding the times for each coordinate.
+Elevation is read from the third coordinate value
+unless an "elevation" property is present.
+
+Each line is a separate track segment, and consecutive
+Point features are decoded as a single segment.
+
+see https://tools.ietf.org/html/rfc7946
+
+*/
+
+func isGeoJSON(p []byte) bool {
+	t, ok := jsonObjectString(p, "type")
+	return ok && (t == "FeatureCollection" || t == "Feature")
+}
+
+func newGeoJSON(r io.Reader) (PointReader, error) {
+	j := json.NewDecoder(r)
+	if err := readTokens(j, json.Delim('{')); err != nil {
+		return nil, ErrFormat
+	}
+
+	return &geoJSON{j: j, trk: -1, seg: -1}, nil
+}
+
+type geoJSON struct {
+	j *json.Decoder
+
+	inFeatures bool // within the "features" array
+	eof        bool
+
+	// top level object members
+	typ  string
+	root geoJSONFeature
+
+	pts []Point // points decoded but not yet returned
+
+	trk, seg  int  // current track and segment index
+	lastPoint bool // last feature decoded was a Point
+
+	md Metadata
+}
+
+// Metadata returns the name and description
+// feature properties as track names and descriptions.
+func (g *geoJSON) Metadata() Metadata { return g.md }
+func (g *geoJSON) inputPos() inputPos { return jsonInputPos(g.j) }
+
+func (g *geoJSON) ReadPoint() (Point, error) {
+	for len(g.pts) == 0 {
+		if err := g.next(); err != nil {
+			return Point{}, err
+		}
+	}
+
+	p := g.pts[0]
+	g.pts = g.pts[1:]
+	return p, nil
+}
+
+// next decodes the next feature.
+func (g *geoJSON) next() error {
+	if g.eof {
+		return io.EOF
+	}
+
+	if g.inFeatures {
+		if g.j.More() {
+			var f geoJSONFeature
+			if err := decodeJSONValue(g.j, &f); err != nil {
+				return err
+			}
+			return g.feature(&f)
+		}
+
+		// end of features
+		g.inFeatures = false
+		return readTokens(g.j, json.Delim(']'))
+	}
+
+	tok, err := g.j.Token()
+	if err != nil {
+		return err
+	}
+
+	if tok == json.Delim('}') {
+		// end of document
+		g.eof = true
+		if g.typ == "Feature" {
+			return g.feature(&g.root)
+		}
+		return io.EOF
+	}
+
+	var v interface{}
+	switch tok {
+	case "features":
+		g.inFeatures = true
+		return readTokens(g.j, json.Delim('['))
+	case "type":
+		v = &g.typ
+	case "geometry":
+		v = &g.root.Geometry
+	case "properties":
+		v = &g.root.Properties
+	default:
+		return skipJSONValue(g.j)
+	}
+
+	return decodeJSONValue(g.j, v)
+}
+
+func (g *geoJSON) feature(f *geoJSONFeature) error {
+	if f.Geometry == nil {
+		return nil
+	}
+
+	if err := g.points(f); err != nil {
+		return err
+	}
+
+	if p := &f.Properties; p.Name != "" || p.Description != "" {
+		t := g.md.track(g.trk)
+		if p.Name != "" {
+			t.Name = p.Name
+		}
+		if p.Description != "" {
+			t.Description = p.Description
+		}
+	}
+	return nil
+}
+
+// points decodes the points of the geometry of f.
+func (g *geoJSON) points(f *geoJSONFeature) error {
+	c, p := f.Geometry.Coordinates, &f.Properties
+
+	switch f.Geometry.Type {
+
+	case "Point":
+		var pos []float64
+		if err := json.Unmarshal(c, &pos); err != nil {
+			return newDecodeError(err)
+		}
+		var acc, ele, vacc *float64
+		if err := p.values(&acc, &ele, &vacc); err != nil {
+			return err
+		}
+		if ele == nil {
+			ele = p.Altitude
+		}
+		when := p.Time
+		if when == "" && p.TimeLong != nil {
+			when = time.Unix(0, *p.TimeLong*1e6).UTC().Format(time.RFC3339Nano)
+		}
+		pt, err := geoJSONPt(pos, when, acc, ele, vacc)
+		if err != nil {
+			return err
+		}
+		p.motion(&pt)
+		// consecutive Point features form a single segment
+		if !g.lastPoint {
+			g.trk++
+			g.seg++
+			g.lastPoint = true
+		}
+		pt.Trk, pt.Seg = g.trk, g.seg
+		g.pts = append(g.pts, pt)
+
+	case "LineString":
+		var line [][]float64
+		if err := json.Unmarshal(c, &line); err != nil {
+			return newDecodeError(err)
+		}
+		var times []string
+		if err := p.times(&times); err != nil {
+			return err
+		}
+		var acc, ele, vacc []*float64
+		if err := p.values(&acc, &ele, &vacc); err != nil {
+			return err
+		}
+		g.trk++
+		return g.line(line, times, acc, ele, vacc)
+
+	case "MultiLineString":
+		var lines [][][]float64
+		if err := json.Unmarshal(c, &lines); err != nil {
+			return newDecodeError(err)
+		}
+		var times [][]string
+		if err := p.times(&times); err != nil {
+			return err
+		}
+		var acc, ele, vacc [][]*float64
+		if err := p.values(&acc, &ele, &vacc); err != nil {
+			return err
+		}
+		if len(times) != len(lines) {
+			return decodeError("geojson line count mismatch (coordinates: %d, times: %d)",
+				len(lines), len(times))
+		}
+		g.trk++
+		for i := range lines {
+			err := g.line(lines[i], times[i],
+				floatsAt(acc, i), floatsAt(ele, i), floatsAt(vacc, i))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (g *geoJSON) line(line [][]float64, times []string, acc, ele, vacc []*float64) error {
+	if len(times) != len(line) {
+		return decodeError("geojson length mismatch (coordinates: %d, times: %d)",
+			len(line), len(times))
+	}
+	g.seg++
+	g.lastPoint = false
+	for i := range line {
+		pt, err := geoJSONPt(line[i], times[i],
+			floatAt(acc, i), floatAt(ele, i), floatAt(vacc, i))
+		if err != nil {
+			return err
+		}
+		pt.Trk, pt.Seg = g.trk, g.seg
+		g.pts = append(g.pts, pt)
+	}
+	return nil
+}
+
+func geoJSONPt(pos []float64, when string, acc, ele, vacc *float64) (Point, error) {
+	if len(pos) < 2 {
+		return Point{}, decodeError("invalid geojson position %v", pos)
+	}
+
+	ts, err := time.Parse(time.RFC3339, when)
+	if err != nil {
+		return Point{}, decodeError("invalid timestamp %q", when)
+	}
+
+	pt := Pt(ts.UTC(), pos[1], pos[0])
+	if acc != nil {
+		pt.Acc = *acc
+	}
+	if ele == nil && len(pos) > 2 {
+		ele = &pos[2]
+	}
+	if ele != nil {
+		pt.Ele.Valid = true
+		pt.Ele.Float64 = *ele
+		if vacc != nil {
+			pt.Ele.Acc = *vacc
+		}
+	}
+	return pt, nil
+}
+
+func floatAt(v []*float64, i int) *float64 {
+	if i < len(v) {
+		return v[i]
+	}
+	return nil
+}
+
+func floatsAt(v [][]*float64, i int) []*float64 {
+	if i < len(v) {
+		return v[i]
+	}
+	return nil
+}
+
+type geoJSONFeature struct {
+	Geometry *struct {
+		Type        string          `json:"type"`
+		Coordinates json.RawMessage `json:"coordinates"`
+	} `json:"geometry"`
+
+	Properties geoJSONProps `json:"properties"`
+}
+
+type geoJSONProps struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+
+	Time string `json:"time"`
+
+	Times      json.RawMessage `json:"times"`
+	CoordTimes json.RawMessage `json:"coordTimes"`
+
+	Accuracy         json.RawMessage `json:"accuracy"`
+	Elevation        json.RawMessage `json:"elevation"`
+	VerticalAccuracy json.RawMessage `json:"verticalAccuracy"`
+
+	// GPSLogger for Android Point properties
+	TimeLong *int64   `json:"time_long"`
+	Altitude *float64 `json:"altitude"`
+	Speed    *float64 `json:"speed"`
+	Bearing  *float64 `json:"bearing"`
+	Battery  *float64 `json:"battery"`
+}
+
+// motion stores the speed, bearing and battery properties in pt.
+func (p *geoJSONProps) motion(pt *Point) {
+	if p.Speed != nil {
+		pt.Speed = NullFloat64{Valid: true, Float64: *p.Speed}
+	}
+	if p.Bearing != nil {
+		pt.Course = NullFloat64{Valid: true, Float64: *p.Bearing}
+	}
+	if p.Battery != nil {
+		pt.Sensors = Sensors{Battery: *p.Battery}
+	}
+}
+
+// times decodes the times or coordTimes property into v.
+func (p *geoJSONProps) times(v interface{}) error {
+	raw := p.Times
+	if raw == nil {
+		raw = p.CoordTimes
+	}
+	if raw == nil {
+		return decodeError("geojson line without times")
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return newDecodeError(err)
+	}
+	return nil
+}
+
+// values decodes the accuracy, elevation and verticalAccuracy properties.
+func (p *geoJSONProps) values(acc, ele, vacc interface{}) error {
+	for _, x := range []struct {
+		raw json.RawMessage
+		v   interface{}
+	}{
+		{p.Accuracy, acc},
+		{p.Elevation, ele},
+		{p.VerticalAccuracy, vacc},
+	} {
+		if x.raw == nil {
+			continue
+		}
+		if err := json.Unmarshal(x.raw, x.v); err != nil {
+			return newDecodeError(err)
+		}
+	}
+	return nil
+}
+
+func newGeoJSONWriter(w io.Writer) PointWriter {
+	return &geoJSONWriter{w: w}
+}
+
+// geoJSONWriter writes points as a single LineString Feature,
+// or a MultiLineString Feature if the points have multiple segments.
+// Points are kept in memory until Close is called.
+type geoJSONWriter struct {
+	w io.Writer
+
+	trk Track
+	md  Metadata
+}
+
+func (g *geoJSONWriter) SetMetadata(m Metadata) { g.md = m }
+
+func (g *geoJSONWriter) WritePoint(p Point) error {
+	g.trk = append(g.trk, p)
+	return nil
+}
+
+func (g *geoJSONWriter) Close() error {
+	type geometry struct {
+		Type        string      `json:"type"`
+		Coordinates interface{} `json:"coordinates"`
+	}
+	var f struct {
+		Type       string                 `json:"type"`
+		Geometry   *geometry              `json:"geometry"`
+		Properties map[string]interface{} `json:"properties"`
+	}
+	f.Type = "Feature"
+	f.Properties = make(map[string]interface{})
+
+	info := TrackInfo{g.md.Name, g.md.Description}
+	if len(g.trk) != 0 {
+		if t := g.md.Track(g.trk[0].Trk); t != (TrackInfo{}) {
+			info = t
+		}
+	}
+	if info.Name != "" {
+		f.Properties["name"] = info.Name
+	}
+	if info.Description != "" {
+		f.Properties["description"] = info.Description
+	}
+
+	segs := g.trk.Segments()
+	var coords [][][2]float64
+	var times [][]string
+	var acc, ele, vacc [][]*float64
+
+	var hasAcc, hasEle, hasVAcc bool
+	for _, seg := range segs {
+		n := len(seg)
+		sc := make([][2]float64, n)
+		st := make([]string, n)
+		sa := make([]*float64, n)
+		se := make([]*float64, n)
+		sv := make([]*float64, n)
+		for i := range seg {
+			p := &seg[i]
+			sc[i] = [2]float64{p.Long, p.Lat}
+			st[i] = p.Time.UTC().Format(time.RFC3339Nano)
+			if p.Acc < NoAccuracy {
+				sa[i], hasAcc = &p.Acc, true
+			}
+			if p.Ele.Valid {
+				se[i], hasEle = &p.Ele.Float64, true
+				if p.Ele.Acc < NoAccuracy {
+					sv[i], hasVAcc = &p.Ele.Acc, true
+				}
+			}
+		}
+		coords = append(coords, sc)
+		times = append(times, st)
+		acc = append(acc, sa)
+		ele = append(ele, se)
+		vacc = append(vacc, sv)
+	}
+
+	switch {
+	case len(g.trk) == 0:
+	case len(segs) > 1:
+		f.Geometry = &geometry{"MultiLineString", coords}
+		f.Properties["times"] = times
+		if hasAcc {
+			f.Properties["accuracy"] = acc
+		}
+		if hasEle {
+			f.Properties["elevation"] = ele
+		}
+		if hasVAcc {
+			f.Properties["verticalAccuracy"] = vacc
+		}
+	case len(g.trk) == 1:
+		f.Geometry = &geometry{"Point", coords[0][0]}
+		f.Properties["time"] = times[0][0]
+		if hasAcc {
+			f.Properties["accuracy"] = acc[0][0]
+		}
+		if hasEle {
+			f.Properties["elevation"] = ele[0][0]
+		}
+		if hasVAcc {
+			f.Properties["verticalAccuracy"] = vacc[0][0]
+		}
+	default:
+		f.Geometry = &geometry{"LineString", coords[0]}
+		f.Properties["times"] = times[0]
+		if hasAcc {
+			f.Properties["accuracy"] = acc[0]
+		}
+		if hasEle {
+			f.Properties["elevation"] = ele[0]
+		}
+		if hasVAcc {
+			f.Properties["verticalAccuracy"] = vacc[0]
+		}
+	}
+
+	e := json.NewEncoder(g.w)
+	return e.Encode(f)
+}
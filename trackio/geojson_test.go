@@ -0,0 +1,155 @@
+package trackio_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tajtiattila/track/trackio"
+)
+
+var sampleGeoJSON = `{
+  "type": "FeatureCollection",
+  "features": [ {
+    "type": "Feature",
+    "properties": {
+      "name": "morning walk",
+      "coordTimes": [ "2018-01-01T10:00:00Z", "2018-01-01T10:00:05Z", "2018-01-01T10:00:10Z" ]
+    },
+    "geometry": {
+      "type": "LineString",
+      "coordinates": [ [19.05, 47.5, 120], [19.0501, 47.5001, 121], [19.0502, 47.5002, 122] ]
+    }
+  }, {
+    "type": "Feature",
+    "geometry": { "type": "Point", "coordinates": [19.06, 47.51] },
+    "properties": { "time": "2018-01-01T10:01:00Z", "accuracy": 12 }
+  }, {
+    "type": "Feature",
+    "geometry": { "type": "Polygon", "coordinates": [ [ [0, 0], [1, 0], [1, 1], [0, 0] ] ] },
+    "properties": null
+  }, {
+    "type": "Feature",
+    "geometry": {
+      "type": "MultiLineString",
+      "coordinates": [
+        [ [19.07, 47.52], [19.0701, 47.5201] ],
+        [ [19.08, 47.53] ]
+      ]
+    },
+    "properties": {
+      "times": [
+        [ "2018-01-01T10:02:00Z", "2018-01-01T10:02:05Z" ],
+        [ "2018-01-01T10:03:00Z" ]
+      ],
+      "accuracy": [ [ 5, null ], [ 8 ] ]
+    }
+  } ]
+}`
+
+var sampleGeoJSONFeature = `{
+  "geometry": { "type": "Point", "coordinates": [19.06, 47.51, 130] },
+  "properties": { "time": "2018-01-01T10:01:00Z" },
+  "type": "Feature"
+}`
+
+func TestGeoJSON(t *testing.T) {
+	d := trackio.NewDecoder(strings.NewReader(sampleGeoJSON))
+	d.Accuracy = trackio.NoAccuracy
+	trk, err := d.Track()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const wantLen = 7
+	if len(trk) != wantLen {
+		t.Fatalf("track length mismatch: want %d got %d", wantLen, len(trk))
+	}
+
+	pointEqual(t, trk[0], trackio.Pt(
+		time.Date(2018, 1, 1, 10, 0, 0, 0, time.UTC),
+		47.5,
+		19.05,
+	))
+	if p := trk[2]; !p.Ele.Valid || p.Ele.Float64 != 122 {
+		t.Errorf("got elevation %+v, want 122", p.Ele)
+	}
+	if p := trk[3]; p.Acc != 12 || p.Ele.Valid {
+		t.Errorf("got accuracy %v elevation %+v, want 12 and none", p.Acc, p.Ele)
+	}
+	pointEqual(t, trk[6], trackio.Pt(
+		time.Date(2018, 1, 1, 10, 3, 0, 0, time.UTC),
+		47.53,
+		19.08,
+	))
+	for i, want := range []float64{5, trackio.NoAccuracy, 8} {
+		if got := trk[4+i].Acc; got != want {
+			t.Errorf("point %d: got accuracy %v, want %v", 4+i, got, want)
+		}
+	}
+}
+
+func TestGeoJSONFeature(t *testing.T) {
+	if f, ok := trackio.DetectFormat([]byte(sampleGeoJSONFeature)); !ok || f != "geojson" {
+		t.Fatalf("detected format %q", f)
+	}
+
+	trk, err := trackio.NewDecoder(strings.NewReader(sampleGeoJSONFeature)).Track()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(trk) != 1 {
+		t.Fatalf("track length mismatch: want 1 got %d", len(trk))
+	}
+
+	pointEqual(t, trk[0], trackio.Pt(
+		time.Date(2018, 1, 1, 10, 1, 0, 0, time.UTC),
+		47.51,
+		19.06,
+	))
+	if p := trk[0]; !p.Ele.Valid || p.Ele.Float64 != 130 {
+		t.Errorf("got elevation %+v, want 130", p.Ele)
+	}
+}
+
+var sampleGPSLogger = `{"type": "FeatureCollection","features": [
+{"type": "Feature","properties":{"time":"2018-01-01T10:00:00.000Z","provider":"gps","time_long":1514800800000,"accuracy":8,"altitude":120,"bearing":270,"speed":1.5,"battery":87},"geometry":{"type":"Point","coordinates":[19.05,47.5]}},
+{"type": "Feature","properties":{"provider":"gps","time_long":1514800805000,"accuracy":10},"geometry":{"type":"Point","coordinates":[19.0501,47.5001]}}]}`
+
+func TestGeoJSONGPSLogger(t *testing.T) {
+	trk, err := trackio.NewDecoder(strings.NewReader(sampleGPSLogger)).Track()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(trk) != 2 {
+		t.Fatalf("track length mismatch: want 2 got %d", len(trk))
+	}
+
+	p := trk[0]
+	if p.Acc != 8 || !p.Ele.Valid || p.Ele.Float64 != 120 {
+		t.Errorf("got accuracy %v elevation %+v, want 8 and 120", p.Acc, p.Ele)
+	}
+	if p.Speed.Float64 != 1.5 || p.Course.Float64 != 270 || p.Sensors[trackio.Battery] != 87 {
+		t.Errorf("got speed %v course %v sensors %v", p.Speed, p.Course, p.Sensors)
+	}
+
+	want := time.Date(2018, 1, 1, 10, 0, 5, 0, time.UTC)
+	if p := trk[1]; !p.Time.Equal(want) || p.Seg != 0 {
+		t.Errorf("got time %v seg %d, want %v and 0", p.Time, p.Seg, want)
+	}
+}
+
+func TestGeoJSONInvalid(t *testing.T) {
+	bad := strings.Replace(sampleGeoJSON, `"features": [ {`, `"features": [ {
+    "type": "Feature",
+    "geometry": { "type": "Point", "coordinates": "19.06 47.51" }
+  }, {
+    "type": "Feature",
+    "geometry": { "type": "Point", "coordinates": [19.06, 47.51] },
+    "properties": { "time": "yesterday" }
+  }, {`, 1)
+	truncated := sampleGeoJSON[:strings.Index(sampleGeoJSON, "Polygon")]
+	testInvalidJSON(t, "geojson", sampleGeoJSON, bad, 2, truncated)
+}
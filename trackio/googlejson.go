@@ -5,17 +5,21 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
+	"sort"
 	"strconv"
 	"time"
 )
 
 func init() {
 	RegisterFormat("googlejson", isGoogleJSON, newGoogleJSON)
+	RegisterEncoder("googlejson", newGoogleJSONWriter)
 }
 
 func isGoogleJSON(p []byte) bool {
 	j := json.NewDecoder(bytes.NewReader(p))
-	return readGoogleJSONPrefix(j) == nil
+	// Overland batches have the same prefix
+	return readGoogleJSONPrefix(j) == nil && !isOverland(p)
 }
 
 func readGoogleJSONPrefix(j *json.Decoder) error {
@@ -34,16 +38,22 @@ func readGoogleJSONPrefix(j *json.Decoder) error {
     "accuracy" : 20,
     "altitude" : 342,
     "verticalAccuracy" : 2,
+    "velocity" : 3,
+    "heading" : 270,
+    "deviceTag" : 1234567890,
     "activity" : [ ... ],
   }, { "timestampMs": ... }
 ]}
 
+Newer exports (Records.json) have an ISO 8601 "timestamp"
+such as "2022-01-12T17:18:24.190Z" in place of "timestampMs".
+
 */
 
 func newGoogleJSON(r io.Reader) (PointReader, error) {
 	j := json.NewDecoder(r)
 	if err := readGoogleJSONPrefix(j); err != nil {
-		panic("impossible")
+		return nil, ErrFormat
 	}
 
 	return &googleJSON{j: j}, nil
@@ -51,8 +61,15 @@ func newGoogleJSON(r io.Reader) (PointReader, error) {
 
 type googleJSON struct {
 	j *json.Decoder
+
+	md Metadata
 }
 
+// Metadata returns the device tag of the first point
+// having one as Device.
+func (pr *googleJSON) Metadata() Metadata { return pr.md }
+func (pr *googleJSON) inputPos() inputPos { return jsonInputPos(pr.j) }
+
 func (pr *googleJSON) ReadPoint() (Point, error) {
 	if !pr.j.More() {
 		return Point{}, io.EOF
@@ -60,6 +77,7 @@ func (pr *googleJSON) ReadPoint() (Point, error) {
 
 	var p struct {
 		TimestampMs string `json:"timestampMs"`
+		Timestamp   string `json:"timestamp"`
 
 		LatE7  float64 `json:"latitudeE7"`
 		LongE7 float64 `json:"longitudeE7"`
@@ -68,17 +86,27 @@ func (pr *googleJSON) ReadPoint() (Point, error) {
 
 		Alt  json.Number `json:"altitude"`
 		VAcc json.Number `json:"verticalAccuracy"`
+
+		Velocity json.Number `json:"velocity"`
+		Heading  json.Number `json:"heading"`
+
+		Activity []googleJSONActivity `json:"activity"`
+
+		DeviceTag json.Number `json:"deviceTag"`
 	}
-	if err := pr.j.Decode(&p); err != nil {
-		return Point{}, &DecodeError{err}
+	if err := decodeJSONValue(pr.j, &p); err != nil {
+		return Point{}, err
+	}
+
+	if pr.md.Device == "" {
+		pr.md.Device = p.DeviceTag.String()
 	}
 
-	ms, err := strconv.ParseInt(p.TimestampMs, 0, 64)
+	ts, err := googleJSONTime(p.TimestampMs, p.Timestamp)
 	if err != nil {
-		return Point{}, decodeError("invalid timestamp %v", p)
+		return Point{}, err
 	}
 
-	ts := time.Unix(ms/1000, (ms%1000)*1e6).UTC()
 	pt := Point{
 		Time: ts,
 		Lat:  p.LatE7 / 1e7,
@@ -100,9 +128,141 @@ func (pr *googleJSON) ReadPoint() (Point, error) {
 		}
 	}
 
+	if v, err := p.Velocity.Float64(); err == nil {
+		pt.Speed = NullFloat64{Valid: true, Float64: v}
+	}
+	if v, err := p.Heading.Float64(); err == nil {
+		pt.Course = NullFloat64{Valid: true, Float64: v}
+	}
+
+	// use the activity recorded first for the point
+	if len(p.Activity) != 0 {
+		for _, a := range p.Activity[0].Activity {
+			pt.Activity = append(pt.Activity, Activity{a.Type, a.Confidence})
+		}
+		sort.SliceStable(pt.Activity, func(i, j int) bool {
+			return pt.Activity[i].Confidence > pt.Activity[j].Confidence
+		})
+	}
+
 	return pt, nil
 }
 
+// googleJSONTime parses the timestampMs value of older exports,
+// or the ISO 8601 timestamp value of newer (Records.json) exports.
+func googleJSONTime(timestampMs, timestamp string) (time.Time, error) {
+	if timestampMs == "" && timestamp != "" {
+		t, err := time.Parse(time.RFC3339, timestamp)
+		if err != nil {
+			return time.Time{}, decodeError("invalid timestamp %q", timestamp)
+		}
+		return t.UTC(), nil
+	}
+
+	ms, err := strconv.ParseInt(timestampMs, 0, 64)
+	if err != nil {
+		return time.Time{}, decodeError("invalid timestamp %q", timestampMs)
+	}
+	return time.Unix(ms/1000, (ms%1000)*1e6).UTC(), nil
+}
+
+type googleJSONActivity struct {
+	TimestampMs string                   `json:"timestampMs"`
+	Activity    []googleJSONActivityType `json:"activity"`
+}
+
+type googleJSONActivityType struct {
+	Type       string `json:"type"`
+	Confidence int    `json:"confidence"`
+}
+
+func newGoogleJSONWriter(w io.Writer) PointWriter {
+	return &googleJSONWriter{w: newErrWriter(w)}
+}
+
+type googleJSONWriter struct {
+	w *errWriter
+
+	n int // points written
+
+	deviceTag *int64
+}
+
+// SetMetadata sets the deviceTag of points written
+// if m.Device is an integer.
+func (g *googleJSONWriter) SetMetadata(m Metadata) {
+	g.deviceTag = nil
+	if v, err := strconv.ParseInt(m.Device, 10, 64); err == nil {
+		g.deviceTag = &v
+	}
+}
+
+func (g *googleJSONWriter) WritePoint(p Point) error {
+	if g.n == 0 {
+		g.w.WriteString(`{"locations" : [ `)
+	} else {
+		g.w.WriteString(", ")
+	}
+	g.n++
+
+	var jp struct {
+		Ts     string   `json:"timestampMs"`
+		LatE7  float64  `json:"latitudeE7"`
+		LongE7 float64  `json:"longitudeE7"`
+		Acc    *float64 `json:"accuracy,omitempty"`
+		Ele    *float64 `json:"altitude,omitempty"`
+		VAcc   *float64 `json:"verticalAccuracy,omitempty"`
+
+		Velocity *float64 `json:"velocity,omitempty"`
+		Heading  *float64 `json:"heading,omitempty"`
+
+		Activity []googleJSONActivity `json:"activity,omitempty"`
+
+		Device *int64 `json:"deviceTag,omitempty"`
+	}
+	jp.Ts = strconv.FormatInt(p.Time.UnixNano()/1e6, 10)
+	jp.LatE7 = math.Floor(p.Lat*1e7 + 0.5)
+	jp.LongE7 = math.Floor(p.Long*1e7 + 0.5)
+	jp.Device = g.deviceTag
+
+	if p.Acc < NoAccuracy {
+		jp.Acc = &p.Acc
+	}
+	if p.Ele.Valid {
+		jp.Ele = &p.Ele.Float64
+		if p.Ele.Acc < NoAccuracy {
+			jp.VAcc = &p.Ele.Acc
+		}
+	}
+	if p.Speed.Valid {
+		jp.Velocity = &p.Speed.Float64
+	}
+	if p.Course.Valid {
+		jp.Heading = &p.Course.Float64
+	}
+	if len(p.Activity) != 0 {
+		a := googleJSONActivity{TimestampMs: jp.Ts}
+		for _, x := range p.Activity {
+			a.Activity = append(a.Activity, googleJSONActivityType{x.Type, x.Confidence})
+		}
+		jp.Activity = []googleJSONActivity{a}
+	}
+	v, err := json.MarshalIndent(jp, " ", " ")
+	if err != nil {
+		return err
+	}
+	g.w.Write(v)
+	return g.w.Err()
+}
+
+func (g *googleJSONWriter) Close() error {
+	if g.n == 0 {
+		g.w.WriteString(`{"locations" : [`)
+	}
+	g.w.WriteString(" ]\n}\n")
+	return g.w.Err()
+}
+
 func readTokens(j *json.Decoder, tokens ...json.Token) error {
 	for _, w := range tokens {
 		tok, err := j.Token()
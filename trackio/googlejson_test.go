@@ -2,6 +2,8 @@ package trackio_test
 
 import (
 	"bytes"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -109,4 +111,90 @@ func TestGoogleJSON(t *testing.T) {
 	ts, _ := time.Parse(time.RFC3339, "2008-10-03T07:40:49Z")
 
 	pointEqual(t, trk[0], trackio.Pt(ts, 52.3190516, 9.4098216))
+
+	want := []trackio.Activity{{"ON_FOOT", 100}, {"WALKING", 100}}
+	if got := trk[6].Activity; !reflect.DeepEqual(got, want) {
+		t.Errorf("got activity %v, want %v", got, want)
+	}
+}
+
+var sampleGoogleJSONRecords = `{
+  "locations": [{
+    "latitudeE7": 474979937,
+    "longitudeE7": 190403594,
+    "accuracy": 13,
+    "source": "WIFI",
+    "deviceTag": 1234567890,
+    "timestamp": "2022-01-12T17:18:24.190Z"
+  }, {
+    "latitudeE7": 474989937,
+    "longitudeE7": 190413594,
+    "accuracy": 8,
+    "activity": [{
+      "activity": [{ "type": "STILL", "confidence": 90 }],
+      "timestamp": "2022-01-12T17:19:30.000Z"
+    }],
+    "source": "GPS",
+    "deviceTag": 1234567890,
+    "timestamp": "2022-01-12T17:19:24Z"
+  }]
+}`
+
+func TestGoogleJSONRecords(t *testing.T) {
+	d := trackio.NewDecoder(strings.NewReader(sampleGoogleJSONRecords))
+	trk, err := d.Track()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const wantLen = 2
+	if len(trk) != wantLen {
+		t.Fatalf("track length mismatch: want %d got %d", wantLen, len(trk))
+	}
+
+	ts := time.Date(2022, 1, 12, 17, 18, 24, 190e6, time.UTC)
+	pointEqual(t, trk[0], trackio.Pt(ts, 47.4979937, 19.0403594))
+	pointEqual(t, trk[1], trackio.Pt(ts.Add(60*time.Second-190*time.Millisecond), 47.4989937, 19.0413594))
+
+	if dev := d.Metadata().Device; dev != "1234567890" {
+		t.Errorf("got device %q, want %q", dev, "1234567890")
+	}
+}
+
+func TestGoogleJSONRecordsInvalid(t *testing.T) {
+	bad := strings.Replace(sampleGoogleJSONRecords, `"locations": [{`,
+		`"locations": [{ "latitudeE7": "north" }, {`, 1)
+	truncated := sampleGoogleJSONRecords[:strings.Index(sampleGoogleJSONRecords, `"source": "GPS"`)]
+	testInvalidJSON(t, "googlejson", sampleGoogleJSONRecords, bad, 1, truncated)
+}
+
+func TestGoogleJSONMotion(t *testing.T) {
+	epoch := time.Date(2018, 6, 1, 10, 30, 0, 0, time.UTC)
+
+	p := trackio.Pt(epoch, 47.5, 19.05)
+	p.Speed = trackio.NullFloat64{Valid: true, Float64: 12.5}
+	p.Course = trackio.NullFloat64{Valid: true, Float64: 90}
+	p.Activity = []trackio.Activity{{"IN_VEHICLE", 80}, {"STILL", 10}}
+
+	buf := new(bytes.Buffer)
+	if err := trackio.NewEncoder(buf, "googlejson").Encode(trackio.Track{p}); err != nil {
+		t.Fatal(err)
+	}
+
+	trk, err := trackio.NewDecoder(buf).Track()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(trk) != 1 {
+		t.Fatalf("track length mismatch: want 1 got %d", len(trk))
+	}
+
+	g := trk[0]
+	if g.Speed != p.Speed || g.Course != p.Course {
+		t.Errorf("got speed %+v course %+v, want %+v and %+v", g.Speed, g.Course, p.Speed, p.Course)
+	}
+	if !reflect.DeepEqual(g.Activity, p.Activity) {
+		t.Errorf("got activity %v, want %v", g.Activity, p.Activity)
+	}
 }
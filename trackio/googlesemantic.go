@@ -0,0 +1,266 @@
+package trackio
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"time"
+)
+
+func init() {
+	RegisterFormat("googlesemantic", isGoogleSemantic, newGoogleSemantic)
+}
+
+func isGoogleSemantic(p []byte) bool {
+	j := json.NewDecoder(bytes.NewReader(p))
+	return readGoogleSemanticPrefix(j) == nil
+}
+
+func readGoogleSemanticPrefix(j *json.Decoder) error {
+	return readTokens(j,
+		json.Delim('{'),
+		"timelineObjects",
+		json.Delim('['))
+}
+
+/* Google Semantic Location History JSON format (monthly files):
+
+{"timelineObjects": [ {
+    "activitySegment": {
+      "startLocation": { "latitudeE7": 474979937, "longitudeE7": 190403594 },
+      "endLocation": { "latitudeE7": 475000000, "longitudeE7": 190500000 },
+      "duration": {
+        "startTimestampMs": "1513838344568",
+        "endTimestampMs": "1513839344568"
+      },
+      "distance": 1234,
+      "activityType": "WALKING",
+      "activities": [ { "activityType": "WALKING", "probability": 87.5 }, ... ],
+      "waypointPath": { "waypoints": [ { "latE7": 474979937, "lngE7": 190403594 }, ... ] },
+      "simplifiedRawPath": {
+        "points": [ { "latE7": 474989937, "lngE7": 190413594, "timestampMs": "1513838544568" } ]
+      }
+    }
+  }, {
+    "placeVisit": {
+      "location": {
+        "latitudeE7": 475000000,
+        "longitudeE7": 190500000,
+        "placeId": "ChIJ...",
+        "address": "Budapest, Hungary",
+        "name": "Home"
+      },
+      "duration": { ... },
+      "simplifiedRawPath": { ... }
+    }
+  }
+]}
+
+Newer files have ISO 8601 "startTimestamp", "endTimestamp" and "timestamp"
+values in place of "startTimestampMs", "endTimestampMs" and "timestampMs".
+
+Activity segments are decoded as track points at the start and end
+location, and the timed points of the simplified raw path.
+Place visits are decoded as track points at the place location
+at the start and end of the visit, and the simplified raw path.
+Waypoints have no time information and are ignored.
+Track points have no accuracy information.
+
+Each timeline object is a separate track segment.
+
+*/
+
+func newGoogleSemantic(r io.Reader) (PointReader, error) {
+	j := json.NewDecoder(r)
+	if err := readGoogleSemanticPrefix(j); err != nil {
+		return nil, ErrFormat
+	}
+
+	return &googleSemantic{j: j, seg: -1}, nil
+}
+
+type googleSemantic struct {
+	j *json.Decoder
+
+	seg int // current track segment
+
+	visits []Visit
+	acts   []ActivitySegment
+
+	pts []Point // points decoded but not yet returned
+}
+
+func (g *googleSemantic) Visits() []Visit                     { return g.visits }
+func (g *googleSemantic) ActivitySegments() []ActivitySegment { return g.acts }
+func (g *googleSemantic) inputPos() inputPos                  { return jsonInputPos(g.j) }
+
+func (g *googleSemantic) ReadPoint() (Point, error) {
+	for len(g.pts) == 0 {
+		if !g.j.More() {
+			return Point{}, io.EOF
+		}
+
+		var o struct {
+			Activity *semanticActivity `json:"activitySegment"`
+			Visit    *semanticVisit    `json:"placeVisit"`
+		}
+		if err := decodeJSONValue(g.j, &o); err != nil {
+			return Point{}, err
+		}
+
+		var err error
+		switch {
+		case o.Activity != nil:
+			err = g.activity(o.Activity)
+		case o.Visit != nil:
+			err = g.visit(o.Visit)
+		}
+		if err != nil {
+			return Point{}, err
+		}
+	}
+
+	p := g.pts[0]
+	g.pts = g.pts[1:]
+	return p, nil
+}
+
+func (g *googleSemantic) activity(a *semanticActivity) error {
+	start, end, err := a.Duration.times()
+	if err != nil {
+		return err
+	}
+
+	s := ActivitySegment{
+		Start:     start,
+		End:       end,
+		Type:      a.ActivityType,
+		Distance:  a.Distance,
+		StartLat:  a.StartLocation.LatE7 / 1e7,
+		StartLong: a.StartLocation.LongE7 / 1e7,
+		EndLat:    a.EndLocation.LatE7 / 1e7,
+		EndLong:   a.EndLocation.LongE7 / 1e7,
+	}
+	g.acts = append(g.acts, s)
+
+	var act []Activity
+	for _, x := range a.Activities {
+		act = append(act, Activity{x.ActivityType, int(math.Floor(x.Probability + 0.5))})
+	}
+	if act == nil && a.ActivityType != "" {
+		act = []Activity{{Type: a.ActivityType}}
+	}
+
+	g.seg++
+	n := len(g.pts)
+	g.add(Pt(start, s.StartLat, s.StartLong))
+	if err := g.rawPath(a.RawPath); err != nil {
+		return err
+	}
+	g.add(Pt(end, s.EndLat, s.EndLong))
+	for i := n; i < len(g.pts); i++ {
+		g.pts[i].Activity = act
+	}
+	return nil
+}
+
+func (g *googleSemantic) visit(v *semanticVisit) error {
+	start, end, err := v.Duration.times()
+	if err != nil {
+		return err
+	}
+
+	l := &v.Location
+	x := Visit{
+		Start:   start,
+		End:     end,
+		Lat:     l.LatE7 / 1e7,
+		Long:    l.LongE7 / 1e7,
+		Name:    l.Name,
+		Address: l.Address,
+		PlaceID: l.PlaceID,
+	}
+	g.visits = append(g.visits, x)
+
+	g.seg++
+	g.add(Pt(start, x.Lat, x.Long))
+	if err := g.rawPath(v.RawPath); err != nil {
+		return err
+	}
+	g.add(Pt(end, x.Lat, x.Long))
+	return nil
+}
+
+func (g *googleSemantic) rawPath(p semanticPath) error {
+	for _, x := range p.Points {
+		t, err := googleJSONTime(x.TimestampMs, x.Timestamp)
+		if err != nil {
+			return err
+		}
+		g.add(Pt(t, x.LatE7/1e7, x.LongE7/1e7))
+	}
+	return nil
+}
+
+func (g *googleSemantic) add(p Point) {
+	p.Seg = g.seg
+	g.pts = append(g.pts, p)
+}
+
+type semanticLocation struct {
+	LatE7  float64 `json:"latitudeE7"`
+	LongE7 float64 `json:"longitudeE7"`
+
+	PlaceID string `json:"placeId"`
+	Address string `json:"address"`
+	Name    string `json:"name"`
+}
+
+type semanticDuration struct {
+	StartTimestampMs string `json:"startTimestampMs"`
+	EndTimestampMs   string `json:"endTimestampMs"`
+
+	StartTimestamp string `json:"startTimestamp"`
+	EndTimestamp   string `json:"endTimestamp"`
+}
+
+func (d *semanticDuration) times() (start, end time.Time, err error) {
+	start, err = googleJSONTime(d.StartTimestampMs, d.StartTimestamp)
+	if err == nil {
+		end, err = googleJSONTime(d.EndTimestampMs, d.EndTimestamp)
+	}
+	return start, end, err
+}
+
+type semanticPath struct {
+	Points []struct {
+		LatE7  float64 `json:"latE7"`
+		LongE7 float64 `json:"lngE7"`
+
+		TimestampMs string `json:"timestampMs"`
+		Timestamp   string `json:"timestamp"`
+	} `json:"points"`
+}
+
+type semanticActivity struct {
+	StartLocation semanticLocation `json:"startLocation"`
+	EndLocation   semanticLocation `json:"endLocation"`
+	Duration      semanticDuration `json:"duration"`
+
+	Distance     float64 `json:"distance"`
+	ActivityType string  `json:"activityType"`
+	Activities   []struct {
+		ActivityType string  `json:"activityType"`
+		Probability  float64 `json:"probability"`
+	} `json:"activities"`
+
+	RawPath semanticPath `json:"simplifiedRawPath"`
+}
+
+type semanticVisit struct {
+	Location semanticLocation `json:"location"`
+	Duration semanticDuration `json:"duration"`
+
+	RawPath semanticPath `json:"simplifiedRawPath"`
+}
@@ -0,0 +1,117 @@
+package trackio_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tajtiattila/track/trackio"
+)
+
+var sampleGoogleSemantic = `{
+  "timelineObjects" : [ {
+    "activitySegment" : {
+      "startLocation" : { "latitudeE7" : 474979937, "longitudeE7" : 190403594 },
+      "endLocation" : { "latitudeE7" : 475000000, "longitudeE7" : 190500000 },
+      "duration" : {
+        "startTimestampMs" : "1514800800000",
+        "endTimestampMs" : "1514801400000"
+      },
+      "distance" : 1234,
+      "activityType" : "WALKING",
+      "confidence" : "HIGH",
+      "activities" : [ {
+        "activityType" : "WALKING",
+        "probability" : 87.5
+      }, {
+        "activityType" : "IN_BUS",
+        "probability" : 10.2
+      } ],
+      "waypointPath" : {
+        "waypoints" : [ { "latE7" : 474979937, "lngE7" : 190403594 } ]
+      },
+      "simplifiedRawPath" : {
+        "points" : [ {
+          "latE7" : 474989937,
+          "lngE7" : 190413594,
+          "timestampMs" : "1514801100000",
+          "accuracyMeters" : 10
+        } ]
+      }
+    }
+  }, {
+    "placeVisit" : {
+      "location" : {
+        "latitudeE7" : 475000000,
+        "longitudeE7" : 190500000,
+        "placeId" : "ChIJxyz",
+        "address" : "Andrássy út 1, Budapest",
+        "name" : "Café"
+      },
+      "duration" : {
+        "startTimestamp" : "2018-01-01T10:10:00Z",
+        "endTimestamp" : "2018-01-01T11:00:00.000Z"
+      },
+      "placeConfidence" : "HIGH_CONFIDENCE"
+    }
+  } ]
+}`
+
+func TestGoogleSemantic(t *testing.T) {
+	if f, ok := trackio.DetectFormat([]byte(sampleGoogleSemantic)); !ok || f != "googlesemantic" {
+		t.Fatalf("detected format %q", f)
+	}
+
+	d := trackio.NewDecoder(strings.NewReader(sampleGoogleSemantic))
+	trk, err := d.Track()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const wantLen = 5
+	if len(trk) != wantLen {
+		t.Fatalf("track length mismatch: want %d got %d", wantLen, len(trk))
+	}
+
+	epoch := time.Date(2018, 1, 1, 10, 0, 0, 0, time.UTC)
+	pointEqual(t, trk[0], trackio.Pt(epoch, 47.4979937, 19.0403594))
+	pointEqual(t, trk[1], trackio.Pt(epoch.Add(5*time.Minute), 47.4989937, 19.0413594))
+	pointEqual(t, trk[4], trackio.Pt(epoch.Add(time.Hour), 47.5, 19.05))
+
+	if a := trk[1].Activity; len(a) != 2 || a[0].Type != "WALKING" || a[0].Confidence != 88 {
+		t.Errorf("got activity %v", a)
+	}
+
+	visits := d.Visits()
+	if len(visits) != 1 {
+		t.Fatalf("got %d visits, want 1", len(visits))
+	}
+	want := trackio.Visit{
+		Start:   epoch.Add(10 * time.Minute),
+		End:     epoch.Add(time.Hour),
+		Lat:     47.5,
+		Long:    19.05,
+		Name:    "Café",
+		Address: "Andrássy út 1, Budapest",
+		PlaceID: "ChIJxyz",
+	}
+	if v := visits[0]; v != want {
+		t.Errorf("got visit %+v, want %+v", v, want)
+	}
+
+	acts := d.ActivitySegments()
+	if len(acts) != 1 {
+		t.Fatalf("got %d activity segments, want 1", len(acts))
+	}
+	if a := acts[0]; a.Type != "WALKING" || a.Distance != 1234 ||
+		!a.Start.Equal(epoch) || !a.End.Equal(epoch.Add(10*time.Minute)) {
+		t.Errorf("got activity segment %+v", a)
+	}
+}
+
+func TestGoogleSemanticInvalid(t *testing.T) {
+	bad := strings.Replace(sampleGoogleSemantic, `"timelineObjects" : [ {`,
+		`"timelineObjects" : [ { "activitySegment" : 42 }, {`, 1)
+	truncated := sampleGoogleSemantic[:strings.Index(sampleGoogleSemantic, "placeConfidence")]
+	testInvalidJSON(t, "googlesemantic", sampleGoogleSemantic, bad, 1, truncated)
+}
@@ -0,0 +1,469 @@
+package trackio
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	RegisterFormat("googletimeline", isGoogleTimeline, newGoogleTimeline)
+}
+
+/* Google Timeline JSON format (exported from the device):
+
+{
+  "semanticSegments": [ {
+      "startTime": "2024-01-12T08:00:00.000+01:00",
+      "endTime": "2024-01-12T10:00:00.000+01:00",
+      "timelinePath": [ {
+          "point": "47.4979937°, 19.0403594°",
+          "time": "2024-01-12T08:03:00.000+01:00"
+      } ]
+    }, {
+      "startTime": "2024-01-12T10:00:00.000+01:00",
+      "endTime": "2024-01-12T12:00:00.000+01:00",
+      "visit": { "topCandidate": { "placeLocation": { "latLng": "47.5°, 19.05°" } } }
+    }, {
+      "startTime": "2024-01-12T12:00:00.000+01:00",
+      "endTime": "2024-01-12T12:30:00.000+01:00",
+      "activity": {
+        "start": { "latLng": "47.5°, 19.05°" },
+        "end": { "latLng": "47.52°, 19.07°" },
+        "topCandidate": { "type": "WALKING", "probability": 0.9 }
+      }
+  } ],
+  "rawSignals": [ {
+      "position": {
+        "LatLng": "47.4979937°, 19.0403594°",
+        "accuracyMeters": 13,
+        "altitudeMeters": 120.5,
+        "source": "GPS",
+        "timestamp": "2024-01-12T08:03:01.000+01:00",
+        "speedMetersPerSecond": 1.5
+      }
+  } ]
+}
+
+Timeline exports from iOS devices are an array of semantic segments
+with positions as "geo:47.4979937,19.0403594" and timeline path points
+having a "durationMinutesOffsetFromStartTime" instead of "time".
+
+Visits and activities are decoded as track points at
+the start and end time of the segment. Each semantic segment
+is a separate track segment, and raw signals form a single segment.
+
+*/
+
+func isGoogleTimeline(p []byte) bool {
+	j := json.NewDecoder(bytes.NewReader(p))
+	tok, err := j.Token()
+	if err != nil {
+		return false
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		key, err := j.Token()
+		return err == nil && (key == "semanticSegments" || key == "rawSignals")
+
+	case json.Delim('['):
+		// iOS export: segment keys in the first object
+		if err := readTokens(j, json.Delim('{')); err != nil {
+			return false
+		}
+		var hasStart, hasData bool
+		for j.More() {
+			key, err := j.Token()
+			if err != nil {
+				return false
+			}
+			switch key {
+			case "startTime":
+				hasStart = true
+			case "timelinePath", "visit", "activity":
+				hasData = true
+			}
+			if hasStart && hasData {
+				return true
+			}
+			if err := skipJSONValue(j); err != nil {
+				return false
+			}
+		}
+	}
+	return false
+}
+
+func newGoogleTimeline(r io.Reader) (PointReader, error) {
+	j := json.NewDecoder(r)
+	tok, err := j.Token()
+	if err != nil {
+		return nil, ErrFormat
+	}
+
+	g := &googleTimeline{j: j, seg: -1}
+	if tok == json.Delim('[') {
+		g.array = "semanticSegments"
+		g.topArray = true
+	}
+	return g, nil
+}
+
+type googleTimeline struct {
+	j *json.Decoder
+
+	array    string // name of the current top level array
+	topArray bool   // document is a top level array of segments
+	eof      bool
+
+	seg int // current track segment
+
+	rawSeg    int  // track segment of raw signals
+	hasRawSeg bool // rawSeg is valid
+
+	visits []Visit
+	acts   []ActivitySegment
+
+	pts []Point // points decoded but not yet returned
+}
+
+func (g *googleTimeline) Visits() []Visit                     { return g.visits }
+func (g *googleTimeline) ActivitySegments() []ActivitySegment { return g.acts }
+func (g *googleTimeline) inputPos() inputPos                  { return jsonInputPos(g.j) }
+
+func (g *googleTimeline) ReadPoint() (Point, error) {
+	for len(g.pts) == 0 {
+		if err := g.next(); err != nil {
+			return Point{}, err
+		}
+	}
+
+	p := g.pts[0]
+	g.pts = g.pts[1:]
+	return p, nil
+}
+
+// next decodes the next array element.
+func (g *googleTimeline) next() error {
+	if g.eof {
+		return io.EOF
+	}
+
+	if g.array != "" {
+		if g.j.More() {
+			return g.element()
+		}
+
+		// end of array
+		g.array = ""
+		if g.topArray {
+			g.eof = true
+			return io.EOF
+		}
+		return readTokens(g.j, json.Delim(']'))
+	}
+
+	tok, err := g.j.Token()
+	if err != nil {
+		return err
+	}
+
+	switch tok {
+	case json.Delim('}'):
+		// end of document
+		g.eof = true
+		return io.EOF
+	case "semanticSegments", "rawSignals":
+		g.array = tok.(string)
+		return readTokens(g.j, json.Delim('['))
+	}
+	return skipJSONValue(g.j)
+}
+
+func (g *googleTimeline) element() error {
+	if g.array == "rawSignals" {
+		var s struct {
+			Position *timelinePosition `json:"position"`
+		}
+		if err := decodeJSONValue(g.j, &s); err != nil {
+			return err
+		}
+		if s.Position == nil {
+			// activity record, wifi scan...
+			return nil
+		}
+		if !g.hasRawSeg {
+			g.seg++
+			g.rawSeg, g.hasRawSeg = g.seg, true
+		}
+		pt, err := s.Position.point()
+		pt.Seg = g.rawSeg
+		if err == nil {
+			g.pts = append(g.pts, pt)
+		}
+		return err
+	}
+
+	var s timelineSegment
+	if err := decodeJSONValue(g.j, &s); err != nil {
+		return err
+	}
+	g.seg++
+	pts, err := s.points()
+	for i := range pts {
+		pts[i].Seg = g.seg
+	}
+	g.pts = append(g.pts, pts...)
+	if err == nil {
+		err = g.places(&s)
+	}
+	return err
+}
+
+// places records the visit or activity of s.
+func (g *googleTimeline) places(s *timelineSegment) error {
+	if s.Visit == nil && s.Activity == nil {
+		return nil
+	}
+
+	start, err := parseTimelineTime(s.StartTime)
+	if err != nil {
+		return err
+	}
+	end, err := parseTimelineTime(s.EndTime)
+	if err != nil {
+		return err
+	}
+
+	if v := s.Visit; v != nil {
+		c := &v.TopCandidate
+		lat, long, err := parseTimelineLatLng(string(c.PlaceLocation))
+		if err != nil {
+			return err
+		}
+		g.visits = append(g.visits, Visit{
+			Start:   start,
+			End:     end,
+			Lat:     lat,
+			Long:    long,
+			PlaceID: c.PlaceID,
+		})
+	}
+
+	if a := s.Activity; a != nil {
+		slat, slong, err := parseTimelineLatLng(string(a.Start))
+		if err != nil {
+			return err
+		}
+		elat, elong, err := parseTimelineLatLng(string(a.End))
+		if err != nil {
+			return err
+		}
+		dist, _ := a.Distance.Float64()
+		g.acts = append(g.acts, ActivitySegment{
+			Start:     start,
+			End:       end,
+			Type:      strings.ToUpper(a.TopCandidate.Type),
+			Distance:  dist,
+			StartLat:  slat,
+			StartLong: slong,
+			EndLat:    elat,
+			EndLong:   elong,
+		})
+	}
+
+	return nil
+}
+
+type timelineSegment struct {
+	StartTime string `json:"startTime"`
+	EndTime   string `json:"endTime"`
+
+	TimelinePath []struct {
+		Point string `json:"point"`
+		Time  string `json:"time"`
+
+		// iOS
+		Offset string `json:"durationMinutesOffsetFromStartTime"`
+	} `json:"timelinePath"`
+
+	Visit *struct {
+		TopCandidate struct {
+			PlaceID       string         `json:"placeId"`
+			PlaceLocation timelineLatLng `json:"placeLocation"`
+		} `json:"topCandidate"`
+	} `json:"visit"`
+
+	Activity *struct {
+		Start        timelineLatLng `json:"start"`
+		End          timelineLatLng `json:"end"`
+		Distance     json.Number    `json:"distanceMeters"`
+		TopCandidate struct {
+			Type        string      `json:"type"`
+			Probability json.Number `json:"probability"`
+		} `json:"topCandidate"`
+	} `json:"activity"`
+}
+
+// points returns the track points of s.
+//
+// If there was an error, points decoded successfully are returned
+// together with the first error.
+func (s *timelineSegment) points() ([]Point, error) {
+	start, err := parseTimelineTime(s.StartTime)
+	if err != nil {
+		return nil, err
+	}
+
+	var pts []Point
+	var firstErr error
+	add := func(t time.Time, latLng string) {
+		lat, long, err := parseTimelineLatLng(latLng)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			return
+		}
+		pts = append(pts, Pt(t, lat, long))
+	}
+
+	for _, p := range s.TimelinePath {
+		var t time.Time
+		if p.Time != "" {
+			t, err = parseTimelineTime(p.Time)
+		} else {
+			t, err = timelineOffset(start, p.Offset)
+		}
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		add(t, p.Point)
+	}
+
+	if s.Visit == nil && s.Activity == nil {
+		return pts, firstErr
+	}
+
+	end, err := parseTimelineTime(s.EndTime)
+	if err != nil {
+		return pts, err
+	}
+
+	if v := s.Visit; v != nil {
+		pos := string(v.TopCandidate.PlaceLocation)
+		add(start, pos)
+		add(end, pos)
+	}
+
+	if a := s.Activity; a != nil {
+		n := len(pts)
+		add(start, string(a.Start))
+		add(end, string(a.End))
+		if a.TopCandidate.Type != "" {
+			act := Activity{Type: strings.ToUpper(a.TopCandidate.Type)}
+			if v, err := a.TopCandidate.Probability.Float64(); err == nil {
+				act.Confidence = int(math.Floor(v*100 + 0.5))
+			}
+			for i := n; i < len(pts); i++ {
+				pts[i].Activity = []Activity{act}
+			}
+		}
+	}
+
+	return pts, firstErr
+}
+
+type timelinePosition struct {
+	LatLng    string      `json:"latLng"`
+	Acc       json.Number `json:"accuracyMeters"`
+	Alt       json.Number `json:"altitudeMeters"`
+	Timestamp string      `json:"timestamp"`
+	Speed     json.Number `json:"speedMetersPerSecond"`
+}
+
+func (p *timelinePosition) point() (Point, error) {
+	ts, err := parseTimelineTime(p.Timestamp)
+	if err != nil {
+		return Point{}, err
+	}
+
+	lat, long, err := parseTimelineLatLng(p.LatLng)
+	if err != nil {
+		return Point{}, err
+	}
+
+	pt := Pt(ts, lat, long)
+	if v, err := p.Acc.Float64(); err == nil {
+		pt.Acc = v
+	}
+	if v, err := p.Alt.Float64(); err == nil {
+		pt.Ele.Valid = true
+		pt.Ele.Float64 = v
+	}
+	if v, err := p.Speed.Float64(); err == nil {
+		pt.Speed = NullFloat64{Valid: true, Float64: v}
+	}
+	return pt, nil
+}
+
+// timelineLatLng is a position that appears either as
+// a string, or as an object with a latLng member.
+type timelineLatLng string
+
+func (l *timelineLatLng) UnmarshalJSON(p []byte) error {
+	var s string
+	if err := json.Unmarshal(p, &s); err == nil {
+		*l = timelineLatLng(s)
+		return nil
+	}
+
+	var v struct {
+		LatLng string `json:"latLng"`
+	}
+	if err := json.Unmarshal(p, &v); err != nil {
+		return err
+	}
+	*l = timelineLatLng(v.LatLng)
+	return nil
+}
+
+// parseTimelineLatLng parses positions like
+// "47.4979937°, 19.0403594°" or "geo:47.4979937,19.0403594".
+func parseTimelineLatLng(s string) (lat, long float64, err error) {
+	v := strings.TrimPrefix(strings.TrimSpace(s), "geo:")
+	f := strings.Split(v, ",")
+	if len(f) == 2 {
+		lat, err1 := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(f[0]), "°"), 64)
+		long, err2 := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(f[1]), "°"), 64)
+		if err1 == nil && err2 == nil {
+			return lat, long, nil
+		}
+	}
+	return 0, 0, decodeError("invalid position %q", s)
+}
+
+func parseTimelineTime(s string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, decodeError("invalid timestamp %q", s)
+	}
+	return t.UTC(), nil
+}
+
+// timelineOffset returns start offset by minutes.
+func timelineOffset(start time.Time, minutes string) (time.Time, error) {
+	m, err := strconv.ParseFloat(minutes, 64)
+	if err != nil {
+		return time.Time{}, decodeError("invalid time offset %q", minutes)
+	}
+	return start.Add(time.Duration(m * float64(time.Minute))), nil
+}
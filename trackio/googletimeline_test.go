@@ -0,0 +1,141 @@
+package trackio_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tajtiattila/track/trackio"
+)
+
+var sampleGoogleTimeline = `{
+  "semanticSegments": [ {
+    "startTime": "2024-01-12T08:00:00.000+01:00",
+    "endTime": "2024-01-12T10:00:00.000+01:00",
+    "timelinePath": [ {
+      "point": "47.4979937°, 19.0403594°",
+      "time": "2024-01-12T08:03:00.000+01:00"
+    }, {
+      "point": "47.4989937°, 19.0413594°",
+      "time": "2024-01-12T08:05:00.000+01:00"
+    } ]
+  }, {
+    "startTime": "2024-01-12T10:00:00.000+01:00",
+    "endTime": "2024-01-12T12:00:00.000+01:00",
+    "visit": {
+      "hierarchyLevel": 0,
+      "topCandidate": {
+        "placeId": "ChIJ",
+        "placeLocation": { "latLng": "47.5°, 19.05°" }
+      }
+    }
+  }, {
+    "startTime": "2024-01-12T12:00:00.000+01:00",
+    "endTime": "2024-01-12T12:30:00.000+01:00",
+    "activity": {
+      "start": { "latLng": "47.5°, 19.05°" },
+      "end": { "latLng": "47.52°, 19.07°" },
+      "distanceMeters": 2500.0,
+      "topCandidate": { "type": "walking", "probability": 0.87 }
+    }
+  } ],
+  "rawSignals": [ {
+    "position": {
+      "LatLng": "47.4979937°, 19.0403594°",
+      "accuracyMeters": 13,
+      "altitudeMeters": 120.5,
+      "source": "GPS",
+      "timestamp": "2024-01-12T08:03:01.000+01:00",
+      "speedMetersPerSecond": 1.5
+    }
+  }, {
+    "wifiScan": { "deliveryTime": "2024-01-12T08:03:02.000+01:00", "devicesRecords": [] }
+  } ],
+  "userLocationProfile": { "frequentPlaces": [] }
+}`
+
+var sampleGoogleTimelineIOS = `[ {
+  "endTime" : "2024-01-12T10:00:00.000+01:00",
+  "startTime" : "2024-01-12T08:00:00.000+01:00",
+  "timelinePath" : [ {
+    "point" : "geo:47.497994,19.040359",
+    "durationMinutesOffsetFromStartTime" : "3"
+  }, {
+    "point" : "geo:47.498994,19.041359",
+    "durationMinutesOffsetFromStartTime" : "5"
+  } ]
+}, {
+  "endTime" : "2024-01-12T12:00:00.000+01:00",
+  "startTime" : "2024-01-12T10:00:00.000+01:00",
+  "visit" : {
+    "topCandidate" : { "placeLocation" : "geo:47.500000,19.050000", "probability" : "0.9" }
+  }
+} ]`
+
+func TestGoogleTimeline(t *testing.T) {
+	if f, ok := trackio.DetectFormat([]byte(sampleGoogleTimeline)); !ok || f != "googletimeline" {
+		t.Fatalf("detected format %q", f)
+	}
+
+	d := trackio.NewDecoder(strings.NewReader(sampleGoogleTimeline))
+	d.Accuracy = trackio.NoAccuracy
+	trk, err := d.Track()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const wantLen = 7
+	if len(trk) != wantLen {
+		t.Fatalf("track length mismatch: want %d got %d", wantLen, len(trk))
+	}
+
+	epoch := time.Date(2024, 1, 12, 7, 0, 0, 0, time.UTC)
+	pointEqual(t, trk[0], trackio.Pt(epoch.Add(3*time.Minute), 47.4979937, 19.0403594))
+	pointEqual(t, trk[3], trackio.Pt(epoch.Add(2*time.Hour), 47.5, 19.05))
+	pointEqual(t, trk[6], trackio.Pt(epoch.Add(4*time.Hour+30*time.Minute), 47.52, 19.07))
+
+	raw := trk[1]
+	if raw.Acc != 13 || raw.Ele.Float64 != 120.5 || raw.Speed.Float64 != 1.5 {
+		t.Errorf("got raw signal %+v", raw)
+	}
+
+	want := trackio.Activity{Type: "WALKING", Confidence: 87}
+	if a := trk[6].Activity; len(a) != 1 || a[0] != want {
+		t.Errorf("got activity %v, want %v", a, want)
+	}
+
+	if v := d.Visits(); len(v) != 1 || v[0].PlaceID != "ChIJ" || v[0].Lat != 47.5 {
+		t.Errorf("got visits %+v", v)
+	}
+	if a := d.ActivitySegments(); len(a) != 1 || a[0].Type != "WALKING" || a[0].Distance != 2500 {
+		t.Errorf("got activity segments %+v", a)
+	}
+}
+
+func TestGoogleTimelineIOS(t *testing.T) {
+	if f, ok := trackio.DetectFormat([]byte(sampleGoogleTimelineIOS)); !ok || f != "googletimeline" {
+		t.Fatalf("detected format %q", f)
+	}
+
+	trk, err := trackio.NewDecoder(strings.NewReader(sampleGoogleTimelineIOS)).Track()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const wantLen = 4
+	if len(trk) != wantLen {
+		t.Fatalf("track length mismatch: want %d got %d", wantLen, len(trk))
+	}
+
+	epoch := time.Date(2024, 1, 12, 7, 0, 0, 0, time.UTC)
+	pointEqual(t, trk[0], trackio.Pt(epoch.Add(3*time.Minute), 47.497994, 19.040359))
+	pointEqual(t, trk[1], trackio.Pt(epoch.Add(5*time.Minute), 47.498994, 19.041359))
+	pointEqual(t, trk[3], trackio.Pt(epoch.Add(4*time.Hour), 47.5, 19.05))
+}
+
+func TestGoogleTimelineInvalid(t *testing.T) {
+	bad := strings.Replace(sampleGoogleTimeline, `"rawSignals": [ {`,
+		`"rawSignals": [ { "position": 42 }, {`, 1)
+	truncated := sampleGoogleTimeline[:strings.Index(sampleGoogleTimeline, "wifiScan")]
+	testInvalidJSON(t, "googletimeline", sampleGoogleTimeline, bad, 1, truncated)
+}
@@ -2,6 +2,7 @@ package trackio
 
 import (
 	"encoding/xml"
+	"fmt"
 	"io"
 	"strconv"
 	"time"
@@ -9,12 +10,18 @@ import (
 
 func init() {
 	RegisterFormat("gpx", isXML("gpx"), newGPX)
+	RegisterEncoder("gpx", newGPXWriter)
 }
 
 /* GPX Format:
 
 <?xml .. ?>
 <gpx version="1.0" ..>
+	<wpt lat="45.5" lon="17.8"><name>Start</name><sym>Flag, Blue</sym></wpt>
+	<rte>
+		<rtept lat="45.5" lon="17.8"></rtept>
+		...
+	</rte>
 	<trk>
 		<trkseg>
 			<trkpt lat="45.51832616" lon="17.85887513"><time>2015-05-03T10:16:58Z</time></trkpt>
@@ -30,28 +37,141 @@ func newGPX(r io.Reader) (PointReader, error) {
 
 	doc, err := nextStartElement(d)
 	if err != nil {
-		panic("impossible")
+		return nil, ErrFormat
 	}
 
 	if doc.Name.Local != "gpx" {
-		panic("impossible")
+		return nil, ErrFormat
+	}
+
+	g := &gpx{trk: -1, seg: -1}
+	for _, a := range doc.Attr {
+		if a.Name.Local == "creator" {
+			g.md.Creator = a.Value
+		}
 	}
 
-	return &gpx{
-		td: newXMLTreeDecoder(d, wantXMLPath("trk", "trkseg", "trkpt")),
-	}, nil
+	want := wantXMLPath("trk", "trkseg", "trkpt")
+	g.td = newXMLTreeDecoder(d, func(p []xml.Name, e xml.Name) xmlTreeOp {
+		switch {
+		case len(p) == 0 && (e.Local == "metadata" || e.Local == "name" || e.Local == "desc"):
+			// GPX 1.1 metadata, or GPX 1.0 name and desc
+			return xmlReturn
+		case len(p) == 0 && (e.Local == "wpt" || e.Local == "rte"):
+			return xmlReturn
+		case len(p) == 1 && (e.Local == "name" || e.Local == "desc"):
+			// trk name and desc
+			return xmlReturn
+		}
+
+		op := want(p, e)
+		if op == xmlEnter {
+			// count tracks and segments
+			switch len(p) {
+			case 0:
+				g.trk++
+			case 1:
+				g.seg++
+			}
+		}
+		return op
+	})
+	return g, nil
 }
 
 type gpx struct {
 	td *xmlTreeDecoder
+
+	trk, seg int // current track and segment index
+
+	md Metadata
+
+	wpts []Waypoint
+	rtes []Route
 }
 
+func (g *gpx) Metadata() Metadata    { return g.md }
+func (g *gpx) Waypoints() []Waypoint { return g.wpts }
+func (g *gpx) Routes() []Route       { return g.rtes }
+func (g *gpx) inputPos() inputPos    { return g.td.inputPos() }
+
 func (g *gpx) ReadPoint() (Point, error) {
-	se, err := g.td.next()
-	if err != nil {
-		return Point{}, err
+	for {
+		se, err := g.td.next()
+		if err != nil {
+			return Point{}, err
+		}
+
+		switch se.Name.Local {
+		case "trkpt":
+			return g.decodePt(se)
+		case "metadata":
+			var m struct {
+				Name string `xml:"name"`
+				Desc string `xml:"desc"`
+			}
+			if err := g.td.d.DecodeElement(&m, &se); err != nil {
+				return Point{}, err
+			}
+			g.md.Name, g.md.Description = m.Name, m.Desc
+		case "wpt":
+			var p gpxPt
+			if err := g.td.d.DecodeElement(&p, &se); err != nil {
+				return Point{}, err
+			}
+			w, err := p.waypoint()
+			if err != nil {
+				return Point{}, err
+			}
+			g.wpts = append(g.wpts, w)
+		case "rte":
+			if err := g.decodeRoute(se); err != nil {
+				return Point{}, err
+			}
+		default:
+			s, err := xmlCharData(g.td.d)
+			if err != nil {
+				return Point{}, err
+			}
+			g.setInfo(se.Name.Local, s)
+		}
 	}
-	return g.decodePt(se)
+}
+
+// setInfo sets the name or desc of the file or the current track.
+func (g *gpx) setInfo(elem, value string) {
+	name, desc := &g.md.Name, &g.md.Description
+	if len(g.td.path) != 0 {
+		t := g.md.track(g.trk)
+		name, desc = &t.Name, &t.Description
+	}
+	if elem == "name" {
+		*name = value
+	} else {
+		*desc = value
+	}
+}
+
+func (g *gpx) decodeRoute(se xml.StartElement) error {
+	var r struct {
+		Name string  `xml:"name"`
+		Desc string  `xml:"desc"`
+		Pts  []gpxPt `xml:"rtept"`
+	}
+	if err := g.td.d.DecodeElement(&r, &se); err != nil {
+		return err
+	}
+
+	rte := Route{Name: r.Name, Description: r.Desc}
+	for i := range r.Pts {
+		w, err := r.Pts[i].waypoint()
+		if err != nil {
+			return err
+		}
+		rte.Points = append(rte.Points, w)
+	}
+	g.rtes = append(g.rtes, rte)
+	return nil
 }
 
 func (g *gpx) decodePt(se xml.StartElement) (Point, error) {
@@ -70,6 +190,8 @@ func (g *gpx) decodePt(se xml.StartElement) (Point, error) {
 		Time: ts,
 		Lat:  p.Lat,
 		Long: p.Long,
+		Trk:  g.trk,
+		Seg:  g.seg,
 	}
 
 	pt.Acc = gpsAccuracy(&p, true)
@@ -80,6 +202,15 @@ func (g *gpx) decodePt(se xml.StartElement) (Point, error) {
 		pt.Ele.Acc = gpsAccuracy(&p, false)
 	}
 
+	if v, err := strconv.ParseFloat(p.Speed, 64); err == nil {
+		pt.Speed = NullFloat64{Valid: true, Float64: v}
+	}
+	if v, err := strconv.ParseFloat(p.Course, 64); err == nil {
+		pt.Course = NullFloat64{Valid: true, Float64: v}
+	}
+
+	p.Ext.apply(&pt)
+
 	return pt, nil
 }
 
@@ -93,6 +224,44 @@ type gpxPt struct {
 	HDOP string  `xml:"hdop"`
 	VDOP string  `xml:"vdop"`
 	PDOP string  `xml:"pdop"`
+
+	// GPX 1.0 only
+	Speed  string `xml:"speed"`
+	Course string `xml:"course"`
+
+	Ext gpxExt `xml:"extensions"`
+
+	// waypoints
+	Name string `xml:"name"`
+	Desc string `xml:"desc"`
+	Sym  string `xml:"sym"`
+}
+
+// waypoint returns p as a Waypoint.
+func (p *gpxPt) waypoint() (Waypoint, error) {
+	w := Waypoint{
+		Lat:         p.Lat,
+		Long:        p.Long,
+		Ele:         Elevation{Acc: NoAccuracy},
+		Name:        p.Name,
+		Description: p.Desc,
+		Symbol:      p.Sym,
+	}
+
+	if p.Time != "" {
+		ts, err := time.Parse(time.RFC3339, p.Time)
+		if err != nil {
+			return Waypoint{}, decodeError("invalid timestamp %q", p.Time)
+		}
+		w.Time = ts
+	}
+
+	if v, err := strconv.ParseFloat(p.Ele, 64); err == nil {
+		w.Ele.Valid = true
+		w.Ele.Float64 = v
+	}
+
+	return w, nil
 }
 
 // Values and the logic below is highly speculative.
@@ -115,3 +284,133 @@ func gpsAccuracy(g *gpxPt, horz bool) float64 {
 	}
 	return baseGPSAccuracy
 }
+
+func newGPXWriter(w io.Writer) PointWriter {
+	return &gpxWriter{w: newErrWriter(w)}
+}
+
+type gpxWriter struct {
+	w *errWriter
+
+	started bool
+
+	trk, seg int // last track and segment index written
+
+	md Metadata
+
+	wpts []Waypoint
+	rtes []Route
+}
+
+func (g *gpxWriter) SetMetadata(m Metadata) { g.md = m }
+
+func (g *gpxWriter) SetWaypoints(wpts []Waypoint, rtes []Route) {
+	g.wpts, g.rtes = wpts, rtes
+}
+
+func (g *gpxWriter) start(p Point) {
+	if g.started {
+		return
+	}
+	g.started = true
+	g.trk, g.seg = p.Trk, p.Seg
+
+	creator := g.md.Creator
+	if creator == "" {
+		creator = "trackio"
+	}
+	fmt.Fprintf(g.w, `<?xml version="1.0" encoding="UTF-8"?>
+<gpx version="1.1" creator="%s" xmlns="http://www.topografix.com/GPX/1/1" xmlns:gpxtpx="%s" xmlns:trak="%s">
+`, xmlEscape(creator), gpxTPXNamespace, gpxSensorNamespace)
+	if g.md.Name != "" || g.md.Description != "" {
+		g.w.WriteString("  <metadata>\n")
+		g.writeInfo("    ", g.md.Name, g.md.Description)
+		g.w.WriteString("  </metadata>\n")
+	}
+	for _, w := range g.wpts {
+		g.writeWaypoint("  ", "wpt", w)
+	}
+	for _, r := range g.rtes {
+		g.w.WriteString("  <rte>\n")
+		g.writeInfo("    ", r.Name, r.Description)
+		for _, w := range r.Points {
+			g.writeWaypoint("    ", "rtept", w)
+		}
+		g.w.WriteString("  </rte>\n")
+	}
+	g.startTrk(p.Trk)
+}
+
+func (g *gpxWriter) writeWaypoint(indent, elem string, w Waypoint) {
+	fmt.Fprintf(g.w, `%s<%s lat="%s" lon="%s">`, indent, elem, fmtFloat(w.Lat), fmtFloat(w.Long))
+	if w.Ele.Valid {
+		fmt.Fprintf(g.w, "<ele>%s</ele>", fmtFloat(w.Ele.Float64))
+	}
+	if !w.Time.IsZero() {
+		fmt.Fprintf(g.w, "<time>%s</time>", w.Time.UTC().Format(time.RFC3339Nano))
+	}
+	if w.Name != "" {
+		fmt.Fprintf(g.w, "<name>%s</name>", xmlEscape(w.Name))
+	}
+	if w.Description != "" {
+		fmt.Fprintf(g.w, "<desc>%s</desc>", xmlEscape(w.Description))
+	}
+	if w.Symbol != "" {
+		fmt.Fprintf(g.w, "<sym>%s</sym>", xmlEscape(w.Symbol))
+	}
+	fmt.Fprintf(g.w, "</%s>\n", elem)
+}
+
+func (g *gpxWriter) startTrk(trk int) {
+	g.w.WriteString("  <trk>\n")
+	t := g.md.Track(trk)
+	g.writeInfo("    ", t.Name, t.Description)
+	g.w.WriteString("    <trkseg>\n")
+}
+
+func (g *gpxWriter) writeInfo(indent, name, desc string) {
+	if name != "" {
+		fmt.Fprintf(g.w, "%s<name>%s</name>\n", indent, xmlEscape(name))
+	}
+	if desc != "" {
+		fmt.Fprintf(g.w, "%s<desc>%s</desc>\n", indent, xmlEscape(desc))
+	}
+}
+
+func (g *gpxWriter) WritePoint(p Point) error {
+	g.start(p)
+
+	switch {
+	case p.Trk != g.trk:
+		g.w.WriteString("    </trkseg>\n  </trk>\n")
+		g.startTrk(p.Trk)
+	case p.Seg != g.seg:
+		g.w.WriteString("    </trkseg>\n    <trkseg>\n")
+	}
+	g.trk, g.seg = p.Trk, p.Seg
+
+	fmt.Fprintf(g.w, `      <trkpt lat="%s" lon="%s">`, fmtFloat(p.Lat), fmtFloat(p.Long))
+	if p.Ele.Valid {
+		fmt.Fprintf(g.w, "<ele>%s</ele>", fmtFloat(p.Ele.Float64))
+	}
+	fmt.Fprintf(g.w, "<time>%s</time>", p.Time.UTC().Format(time.RFC3339Nano))
+
+	// write accuracy values as dilution of precision,
+	// so that gpsAccuracy yields the original values
+	if p.Acc < NoAccuracy {
+		fmt.Fprintf(g.w, "<hdop>%s</hdop>", fmtFloat(p.Acc/baseGPSAccuracy))
+	}
+	if p.Ele.Valid && p.Ele.Acc < NoAccuracy {
+		fmt.Fprintf(g.w, "<vdop>%s</vdop>", fmtFloat(p.Ele.Acc/baseGPSAccuracy))
+	}
+	writeGPXExt(g.w, p)
+	g.w.WriteString("</trkpt>\n")
+
+	return g.w.Err()
+}
+
+func (g *gpxWriter) Close() error {
+	g.start(Point{})
+	g.w.WriteString("    </trkseg>\n  </trk>\n</gpx>\n")
+	return g.w.Err()
+}
@@ -2,6 +2,8 @@ package trackio_test
 
 import (
 	"bytes"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -56,3 +58,227 @@ func TestGPX(t *testing.T) {
 		-122.326897,
 	))
 }
+
+func TestGPXMetadata(t *testing.T) {
+	d := trackio.NewDecoder(strings.NewReader(sampleGPX))
+	if _, err := d.Track(); err != nil {
+		t.Fatal(err)
+	}
+
+	md := d.Metadata()
+	if md.Creator != "Oregon 400t" {
+		t.Errorf("got creator %q, want %q", md.Creator, "Oregon 400t")
+	}
+	if name := md.Track(0).Name; name != "Example GPX Document" {
+		t.Errorf("got track name %q, want %q", name, "Example GPX Document")
+	}
+}
+
+var sampleGPXSegments = `<?xml version="1.0" encoding="UTF-8"?>
+<gpx version="1.1" creator="test" xmlns="http://www.topografix.com/GPX/1/1">
+  <trk>
+    <trkseg>
+      <trkpt lat="47.5" lon="19.05"><time>2018-01-01T10:00:00Z</time></trkpt>
+      <trkpt lat="47.5001" lon="19.0501"><time>2018-01-01T10:00:05Z</time></trkpt>
+    </trkseg>
+    <trkseg>
+      <trkpt lat="47.6" lon="19.15"><time>2018-01-01T10:10:00Z</time></trkpt>
+    </trkseg>
+  </trk>
+  <trk>
+    <trkseg>
+      <trkpt lat="47.7" lon="19.25"><time>2018-01-01T11:00:00Z</time></trkpt>
+      <trkpt lat="47.7001" lon="19.2501"><time>2018-01-01T11:00:05Z</time></trkpt>
+    </trkseg>
+  </trk>
+</gpx>
+`
+
+func TestGPXSegments(t *testing.T) {
+	trk, err := trackio.NewDecoder(strings.NewReader(sampleGPXSegments)).Track()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct{ trk, seg int }{{0, 0}, {0, 0}, {0, 1}, {1, 2}, {1, 2}}
+	if len(trk) != len(want) {
+		t.Fatalf("track length mismatch: want %d got %d", len(want), len(trk))
+	}
+	for i, w := range want {
+		if p := trk[i]; p.Trk != w.trk || p.Seg != w.seg {
+			t.Errorf("point %d: got track %d segment %d, want %d and %d",
+				i, p.Trk, p.Seg, w.trk, w.seg)
+		}
+	}
+
+	if n := len(trk.Segments()); n != 3 {
+		t.Errorf("got %d segments, want 3", n)
+	}
+
+	epoch := time.Date(2018, 1, 1, 10, 0, 0, 0, time.UTC)
+	if _, _, ok := trk.AtSegment(epoch.Add(2 * time.Second)); !ok {
+		t.Error("AtSegment within segment failed")
+	}
+	if _, _, ok := trk.AtSegment(epoch.Add(5 * time.Minute)); ok {
+		t.Error("AtSegment interpolated between segments")
+	}
+	if !trk.HasTime(epoch.Add(5 * time.Minute)) {
+		t.Error("HasTime between segments failed")
+	}
+}
+
+var sampleGPXWaypoints = `<?xml version="1.0" encoding="UTF-8"?>
+<gpx version="1.1" creator="test" xmlns="http://www.topografix.com/GPX/1/1">
+  <wpt lat="47.5" lon="19.05">
+    <ele>120</ele>
+    <time>2018-01-01T10:00:00Z</time>
+    <name>Start</name>
+    <sym>Flag, Blue</sym>
+  </wpt>
+  <wpt lat="47.6" lon="19.15"><name>Lunch &amp; rest</name></wpt>
+  <rte>
+    <name>Plan</name>
+    <rtept lat="47.5" lon="19.05"><name>A</name></rtept>
+    <rtept lat="47.55" lon="19.1"></rtept>
+    <rtept lat="47.6" lon="19.15"><name>B</name></rtept>
+  </rte>
+  <trk>
+    <trkseg>
+      <trkpt lat="47.5" lon="19.05"><time>2018-01-01T10:00:00Z</time></trkpt>
+    </trkseg>
+  </trk>
+</gpx>
+`
+
+func TestGPXWaypoints(t *testing.T) {
+	d := trackio.NewDecoder(strings.NewReader(sampleGPXWaypoints))
+	trk, err := d.Track()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trk) != 1 {
+		t.Fatalf("track length mismatch: want 1 got %d", len(trk))
+	}
+
+	checkWaypoints := func(what string, wpts []trackio.Waypoint, rtes []trackio.Route) {
+		if len(wpts) != 2 {
+			t.Fatalf("%s: got %d waypoints, want 2", what, len(wpts))
+		}
+		w := wpts[0]
+		if w.Name != "Start" || w.Symbol != "Flag, Blue" || w.Lat != 47.5 || w.Long != 19.05 ||
+			!w.Ele.Valid || w.Ele.Float64 != 120 ||
+			!w.Time.Equal(time.Date(2018, 1, 1, 10, 0, 0, 0, time.UTC)) {
+			t.Errorf("%s: got waypoint %+v", what, w)
+		}
+		if w := wpts[1]; w.Name != "Lunch & rest" || !w.Time.IsZero() || w.Ele.Valid {
+			t.Errorf("%s: got waypoint %+v", what, w)
+		}
+
+		if len(rtes) != 1 {
+			t.Fatalf("%s: got %d routes, want 1", what, len(rtes))
+		}
+		r := rtes[0]
+		if r.Name != "Plan" || len(r.Points) != 3 || r.Points[2].Name != "B" || r.Points[1].Lat != 47.55 {
+			t.Errorf("%s: got route %+v", what, r)
+		}
+	}
+	checkWaypoints("decode", d.Waypoints(), d.Routes())
+
+	buf := new(bytes.Buffer)
+	e := trackio.NewEncoder(buf, "gpx")
+	e.SetWaypoints(d.Waypoints(), d.Routes())
+	if err := e.Encode(trk); err != nil {
+		t.Fatal(err)
+	}
+
+	d = trackio.NewDecoder(buf)
+	if _, err := d.Track(); err != nil {
+		t.Fatal(err)
+	}
+	checkWaypoints("round trip", d.Waypoints(), d.Routes())
+}
+
+var sampleGPXExtensions = `<?xml version="1.0" encoding="UTF-8"?>
+<gpx version="1.1" creator="test" xmlns="http://www.topografix.com/GPX/1/1"
+  xmlns:gpxtpx="http://www.garmin.com/xmlschemas/TrackPointExtension/v1"
+  xmlns:gpxdata="http://www.cluetrust.com/XML/GPXDATA/1/0"
+  xmlns:osmand="https://osmand.net">
+  <trk>
+    <trkseg>
+      <trkpt lat="47.5" lon="19.05">
+        <time>2018-01-01T10:00:00Z</time>
+        <extensions>
+          <gpxtpx:TrackPointExtension>
+            <gpxtpx:atemp>21.5</gpxtpx:atemp>
+            <gpxtpx:hr>131</gpxtpx:hr>
+            <gpxtpx:cad>85</gpxtpx:cad>
+          </gpxtpx:TrackPointExtension>
+          <power>250</power>
+        </extensions>
+      </trkpt>
+      <trkpt lat="47.5001" lon="19.0501">
+        <time>2018-01-01T10:00:05Z</time>
+        <extensions>
+          <gpxdata:hr>133</gpxdata:hr>
+          <gpxdata:cadence>86</gpxdata:cadence>
+          <gpxdata:temp>21</gpxdata:temp>
+        </extensions>
+      </trkpt>
+      <trkpt lat="47.5002" lon="19.0502">
+        <time>2018-01-01T10:00:10Z</time>
+        <extensions>
+          <osmand:speed>5.2</osmand:speed>
+          <osmand:heading>270</osmand:heading>
+          <osmand:hdop>1.5</osmand:hdop>
+        </extensions>
+      </trkpt>
+    </trkseg>
+  </trk>
+</gpx>
+`
+
+func TestGPXExtensions(t *testing.T) {
+	d := trackio.NewDecoder(strings.NewReader(sampleGPXExtensions))
+	trk, err := d.Track()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(trk) != 3 {
+		t.Fatalf("track length mismatch: want 3 got %d", len(trk))
+	}
+
+	want := []trackio.Sensors{
+		{trackio.Temperature: 21.5, trackio.HeartRate: 131, trackio.Cadence: 85, trackio.Power: 250},
+		{trackio.Temperature: 21, trackio.HeartRate: 133, trackio.Cadence: 86},
+		{"hdop": 1.5},
+	}
+	for i := range want {
+		if got := trk[i].Sensors; !reflect.DeepEqual(got, want[i]) {
+			t.Errorf("point %d: got sensors %v, want %v", i, got, want[i])
+		}
+	}
+
+	if p := trk[2]; p.Speed.Float64 != 5.2 || p.Course.Float64 != 270 {
+		t.Errorf("got speed %+v course %+v", p.Speed, p.Course)
+	}
+
+	// round trip
+	buf := new(bytes.Buffer)
+	if err := trackio.NewEncoder(buf, "gpx").Encode(trk); err != nil {
+		t.Fatal(err)
+	}
+	if s := buf.String(); !strings.Contains(s, "<trak:power>250</trak:power>") || strings.Contains(s, "<power>") {
+		t.Errorf("sensors outside TrackPointExtension not in own namespace:\n%s", s)
+	}
+	got, err := trackio.NewDecoder(buf).Track()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range trk {
+		g, w := got[i], trk[i]
+		if !reflect.DeepEqual(g.Sensors, w.Sensors) || g.Speed != w.Speed || g.Course != w.Course {
+			t.Errorf("round trip point %d: got %+v, want %+v", i, g, w)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package trackio
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/* GPX extensions:
+
+<trkpt lat="47.5" lon="19.05">
+	<time>2018-01-01T10:00:00Z</time>
+	<extensions>
+		<gpxtpx:TrackPointExtension>
+			<gpxtpx:atemp>21.5</gpxtpx:atemp>
+			<gpxtpx:hr>131</gpxtpx:hr>
+			<gpxtpx:cad>85</gpxtpx:cad>
+			<gpxtpx:speed>5.2</gpxtpx:speed>
+		</gpxtpx:TrackPointExtension>
+		<trak:power>250</trak:power>
+	</extensions>
+</trkpt>
+
+Numeric values within extensions are decoded into Point.Sensors,
+except for speed and course that are decoded into Point.Speed
+and Point.Course. Known element names of Garmin TrackPointExtension
+v1 and v2, Garmin GpxExtensions v3, Cluetrust gpxdata and OsmAnd
+are mapped to the sensor names of this package.
+Elements having other names are stored using their lowercase local name.
+
+Sensor values not in TrackPointExtension are written
+in the namespace of this package using the trak prefix,
+since GPX allows only elements of other namespaces within extensions.
+
+see https://www8.garmin.com/xmlschemas/TrackPointExtensionv2.xsd
+and http://www.cluetrust.com/Schemas/gpxdata10.xsd
+
+*/
+
+const (
+	gpxTPXNamespace    = "http://www.garmin.com/xmlschemas/TrackPointExtension/v2"
+	gpxSensorNamespace = "https://github.com/tajtiattila/track/gpx/sensors/v1"
+)
+
+// gpxExtNames maps lowercase local names of extension elements
+// to sensor names.
+var gpxExtNames = map[string]string{
+	// Garmin TrackPointExtension
+	"atemp": Temperature,
+	"wtemp": WaterTemperature,
+	"depth": Depth,
+	"hr":    HeartRate,
+	"cad":   Cadence,
+
+	// Garmin GpxExtensions
+	"temperature": Temperature,
+
+	// Cluetrust gpxdata
+	"temp":    Temperature,
+	"cadence": Cadence,
+
+	// OsmAnd
+	"heartrate": HeartRate,
+
+	"power": Power,
+	"watts": Power,
+}
+
+// gpxExt holds the numeric leaf values
+// of a GPX extensions element.
+type gpxExt struct {
+	values []gpxExtValue
+}
+
+type gpxExtValue struct {
+	name  string // lowercase local name
+	value float64
+}
+
+func (x *gpxExt) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var text []byte
+	leaf := false
+	depth := 0
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch e := tok.(type) {
+		case xml.StartElement:
+			depth++
+			leaf = true
+			text = text[:0]
+		case xml.CharData:
+			if leaf {
+				text = append(text, e...)
+			}
+		case xml.EndElement:
+			if depth == 0 {
+				return nil
+			}
+			depth--
+			if leaf {
+				s := strings.TrimSpace(string(text))
+				if v, err := strconv.ParseFloat(s, 64); err == nil {
+					x.values = append(x.values, gpxExtValue{strings.ToLower(e.Name.Local), v})
+				}
+			}
+			leaf = false
+		}
+	}
+}
+
+// apply stores the extension values of x in pt.
+func (x *gpxExt) apply(pt *Point) {
+	for _, v := range x.values {
+		switch v.name {
+		case "speed":
+			pt.Speed = NullFloat64{Valid: true, Float64: v.value}
+			continue
+		case "course", "heading":
+			pt.Course = NullFloat64{Valid: true, Float64: v.value}
+			continue
+		}
+
+		name, ok := gpxExtNames[v.name]
+		if !ok {
+			name = v.name
+		}
+		if pt.Sensors == nil {
+			pt.Sensors = make(Sensors)
+		}
+		pt.Sensors[name] = v.value
+	}
+}
+
+// gpxTPXElems holds the elements of Garmin TrackPointExtension v2 in order.
+var gpxTPXElems = []struct {
+	elem, sensor string
+}{
+	{"atemp", Temperature},
+	{"wtemp", WaterTemperature},
+	{"depth", Depth},
+	{"hr", HeartRate},
+	{"cad", Cadence},
+}
+
+// writeGPXExt writes the extensions element for p, if needed.
+//
+// Sensor values known by Garmin TrackPointExtension are written
+// using that namespace. Other sensor values are written
+// in gpxSensorNamespace, sorted by name.
+func writeGPXExt(w *errWriter, p Point) {
+	tpx := new(strings.Builder)
+	for _, e := range gpxTPXElems {
+		if v, ok := p.Sensors[e.sensor]; ok {
+			fmt.Fprintf(tpx, "<gpxtpx:%s>%s</gpxtpx:%s>", e.elem, fmtFloat(v), e.elem)
+		}
+	}
+	if p.Speed.Valid {
+		fmt.Fprintf(tpx, "<gpxtpx:speed>%s</gpxtpx:speed>", fmtFloat(p.Speed.Float64))
+	}
+	if p.Course.Valid {
+		fmt.Fprintf(tpx, "<gpxtpx:course>%s</gpxtpx:course>", fmtFloat(p.Course.Float64))
+	}
+
+	var other []string
+	for name := range p.Sensors {
+		if !isGPXTPXSensor(name) && isXMLName(name) {
+			other = append(other, name)
+		}
+	}
+	sort.Strings(other)
+
+	if tpx.Len() == 0 && len(other) == 0 {
+		return
+	}
+
+	w.WriteString("<extensions>")
+	if tpx.Len() != 0 {
+		fmt.Fprintf(w, "<gpxtpx:TrackPointExtension>%s</gpxtpx:TrackPointExtension>", tpx)
+	}
+	for _, name := range other {
+		fmt.Fprintf(w, "<trak:%s>%s</trak:%s>", name, fmtFloat(p.Sensors[name]), name)
+	}
+	w.WriteString("</extensions>")
+}
+
+func isGPXTPXSensor(name string) bool {
+	for _, e := range gpxTPXElems {
+		if e.sensor == name {
+			return true
+		}
+	}
+	return false
+}
+
+// isXMLName reports whether s can be used
+// as the local name of an xml element.
+func isXMLName(s string) bool {
+	if s == "" || strings.HasPrefix(strings.ToLower(s), "xml") {
+		return false
+	}
+	for i, r := range s {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', r == '_':
+		case i > 0 && ('0' <= r && r <= '9' || r == '-' || r == '.'):
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,308 @@
+package trackio
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	RegisterFormat("igc", isIGC, newIGC)
+}
+
+/* IGC Format:
+
+AXXXABC FLIGHT:1
+HFDTEDATE:160701,01
+HFPLTPILOTINCHARGE:Bloggs Bill D
+HFGTYGLIDERTYPE:Schempp Ventus2
+HFGIDGLIDERID:D-1234
+HFFTYFRTYPE:Manufacturer,Model
+I023638FXA3940SIU
+B1101355206343N00006198WA0058700558010
+B1101455206259N00006295WA0059300556009
+
+The file starts with the A record identifying the flight recorder,
+followed by H header records, such as the date of the flight (HFDTE).
+Older files have the date as HFDTE160701 without the DATE: prefix.
+
+B records hold fixes:
+
+B HHMMSS DDMMmmmN DDDMMmmmE V PPPPP GGGGG [extensions]
+
+where V is the fix validity (A: 3D fix, V: 2D fix or no GNSS altitude),
+PPPPP is the pressure altitude and GGGGG is the GNSS altitude in meters.
+The I record specifies the extensions of B records as
+start and end byte positions (1-based) and three letter codes.
+
+Times are UTC. The date is incremented when the time of day
+jumps backwards, for flights crossing midnight UTC.
+
+Fix accuracy (FXA), ground speed (GSP) and true track (TRT)
+extensions are decoded into Point.Acc, Point.Speed and Point.Course.
+
+Elevation is the GNSS altitude for 3D fixes, and the pressure altitude
+otherwise. Altitude values of zero are treated as missing.
+
+see https://www.fai.org/sites/default/files/igc_fr_specification_2020-11-25_with_al6.pdf
+
+*/
+
+func isIGC(p []byte) bool {
+	p = bytes.TrimLeft(p, " \t\r\n")
+	if len(p) < 4 || p[0] != 'A' {
+		return false
+	}
+	for _, c := range p[1:4] {
+		if !('A' <= c && c <= 'Z' || '0' <= c && c <= '9') {
+			return false
+		}
+	}
+
+	// A record must be followed by header records
+	i := bytes.IndexByte(p, '\n')
+	if i < 0 {
+		return false
+	}
+	p = bytes.TrimLeft(p[i+1:], "\r\n")
+	return len(p) > 0 && p[0] == 'H'
+}
+
+func newIGC(r io.Reader) (PointReader, error) {
+	return &igc{s: newLineScanner(r)}, nil
+}
+
+type igc struct {
+	s *lineScanner
+
+	date    time.Time // date of the current fix
+	hasDate bool
+
+	last    time.Duration // time of day of the last fix
+	hasLast bool
+
+	ext []igcExt // B record extensions from the I record
+
+	md Metadata
+
+	device string // flight recorder from the A record
+	info   []string
+}
+
+// igcExt is a B record extension.
+type igcExt struct {
+	start, end int // byte positions within the B record
+	code       string
+}
+
+func (g *igc) Metadata() Metadata {
+	md := g.md
+	if md.Device == "" {
+		md.Device = g.device
+	}
+	md.Description = strings.Join(g.info, "\n")
+	return md
+}
+
+func (g *igc) inputPos() inputPos { return g.s.inputPos() }
+
+func (g *igc) ReadPoint() (Point, error) {
+	for g.s.Scan() {
+		line := strings.TrimRight(g.s.Text(), " \t\r")
+		if line == "" {
+			continue
+		}
+
+		switch line[0] {
+		case 'A':
+			if g.device == "" && len(line) > 1 {
+				g.device = strings.TrimSpace(line[1:])
+			}
+		case 'H':
+			if err := g.header(line); err != nil {
+				return Point{}, err
+			}
+		case 'I':
+			if err := g.extensions(line); err != nil {
+				return Point{}, err
+			}
+		case 'B':
+			return g.fix(line)
+		}
+	}
+
+	if err := g.s.Err(); err != nil {
+		return Point{}, err
+	}
+	return Point{}, io.EOF
+}
+
+// igcHeaders holds descriptions of IGC header records
+// stored in the Metadata description.
+var igcHeaders = []struct {
+	code, desc string
+}{
+	{"CM2", "Crew"},
+	{"GTY", "Glider type"},
+	{"GID", "Glider ID"},
+	{"CID", "Competition ID"},
+	{"CCL", "Competition class"},
+	{"SIT", "Site"},
+	{"RFW", "Firmware version"},
+	{"RHW", "Hardware version"},
+	{"GPS", "GPS receiver"},
+	{"PRS", "Pressure sensor"},
+	{"DTM", "Datum"},
+	{"TZN", "Time zone offset"},
+}
+
+// header processes the H record line.
+//
+// Records other than the date are stored in the metadata:
+// the pilot as Name, the flight recorder type as Device
+// and other known records in the Description.
+func (g *igc) header(line string) error {
+	if len(line) < 5 {
+		return nil
+	}
+	code, value := line[2:5], line[5:]
+	if i := strings.IndexByte(value, ':'); i >= 0 {
+		value = value[i+1:]
+	}
+	value = strings.TrimSpace(value)
+
+	switch code {
+	case "DTE":
+		// HFDTE160701 or HFDTEDATE:160701,01
+		v := strings.TrimPrefix(line[5:], "DATE:")
+		if len(v) < 6 {
+			return decodeError("invalid igc date %q", line)
+		}
+		date, err := time.Parse("020106", v[:6])
+		if err != nil {
+			return decodeError("invalid igc date %q", line)
+		}
+		g.date, g.hasDate = date, true
+		g.hasLast = false
+		return nil
+	case "PLT":
+		g.md.Name = value
+		return nil
+	case "FTY":
+		g.md.Device = value
+		return nil
+	}
+
+	if value == "" || strings.EqualFold(value, "NIL") || strings.EqualFold(value, "NKN") {
+		return nil
+	}
+	for _, h := range igcHeaders {
+		if h.code == code {
+			g.info = append(g.info, h.desc+": "+value)
+			break
+		}
+	}
+	return nil
+}
+
+// extensions processes the I record line.
+func (g *igc) extensions(line string) error {
+	if len(line) < 3 {
+		return decodeError("invalid igc I record %q", line)
+	}
+	n, err := strconv.Atoi(line[1:3])
+	if err != nil || len(line) < 3+n*7 {
+		return decodeError("invalid igc I record %q", line)
+	}
+
+	g.ext = g.ext[:0]
+	for i := 0; i < n; i++ {
+		f := line[3+i*7 : 3+(i+1)*7]
+		start, err0 := strconv.Atoi(f[0:2])
+		end, err1 := strconv.Atoi(f[2:4])
+		if err0 != nil || err1 != nil || start < 1 || end < start {
+			return decodeError("invalid igc I record %q", line)
+		}
+		g.ext = append(g.ext, igcExt{start - 1, end, f[4:7]})
+	}
+	return nil
+}
+
+// fix decodes the B record line.
+func (g *igc) fix(line string) (Point, error) {
+	if len(line) < 35 {
+		return Point{}, decodeError("short igc B record %q", line)
+	}
+
+	if !g.hasDate {
+		return Point{}, decodeError("igc fix without date")
+	}
+
+	tod, err := nmeaTime(line[1:7])
+	if err != nil {
+		return Point{}, decodeError("invalid igc time %q", line[1:7])
+	}
+	if g.hasLast && tod < g.last-12*time.Hour {
+		// midnight UTC
+		g.date = g.date.AddDate(0, 0, 1)
+	}
+	g.last, g.hasLast = tod, true
+
+	lat, ok0 := igcCoord(line[7:14], line[14], 'N', 'S')
+	long, ok1 := igcCoord(line[15:23], line[23], 'E', 'W')
+	if !ok0 || !ok1 {
+		return Point{}, decodeError("invalid igc position %q", line[7:24])
+	}
+
+	pt := Pt(g.date.Add(tod), lat, long)
+
+	palt, err0 := strconv.Atoi(line[25:30])
+	galt, err1 := strconv.Atoi(line[30:35])
+	switch {
+	case line[24] == 'A' && err1 == nil && galt != 0:
+		pt.Ele.Valid, pt.Ele.Float64 = true, float64(galt)
+	case err0 == nil && palt != 0:
+		pt.Ele.Valid, pt.Ele.Float64 = true, float64(palt)
+	}
+
+	for _, x := range g.ext {
+		if x.end > len(line) {
+			continue
+		}
+		v, err := strconv.Atoi(strings.TrimSpace(line[x.start:x.end]))
+		if err != nil {
+			continue
+		}
+		switch x.code {
+		case "FXA":
+			pt.Acc = float64(v)
+		case "GSP":
+			// km/h
+			pt.Speed = NullFloat64{Valid: true, Float64: float64(v) / 3.6}
+		case "TRT":
+			pt.Course = NullFloat64{Valid: true, Float64: float64(v)}
+		}
+	}
+
+	return pt, nil
+}
+
+// igcCoord parses a coordinate in the form dddmmmmm,
+// where the last five digits are thousands of minutes.
+func igcCoord(v string, hemi, pos, neg byte) (float64, bool) {
+	x, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, false
+	}
+	deg := x / 100000
+	f := float64(deg) + float64(x-deg*100000)/60000
+	switch hemi {
+	case pos:
+		return f, true
+	case neg:
+		return -f, true
+	}
+	return 0, false
+}
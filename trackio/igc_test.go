@@ -0,0 +1,86 @@
+package trackio_test
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tajtiattila/track/trackio"
+)
+
+var sampleIGC = `AXXXABC FLIGHT:1
+HFDTEDATE:160701,01
+HFPLTPILOTINCHARGE:Bloggs Bill D
+HFGTYGLIDERTYPE:Schempp Ventus2
+HFGIDGLIDERID:D-1234
+HFCIDCOMPETITIONID:NIL
+HFFTYFRTYPE:Manufacturer,Model
+I033638FXA3941GSP4244TRT
+B2359585206343N00006198WA0058700558015088012
+B2359595206259S00006295EV005930055601007300
+B0000015206259N00006295EA000000000001007300
+B0000025206259N00006295EA-001200000
+`
+
+func TestIGC(t *testing.T) {
+	if f, ok := trackio.DetectFormat([]byte(sampleIGC)); !ok || f != "igc" {
+		t.Fatalf("detected format %q", f)
+	}
+
+	d := trackio.NewDecoder(strings.NewReader(sampleIGC))
+	d.Accuracy = trackio.NoAccuracy
+	trk, err := d.Track()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(trk) != 4 {
+		t.Fatalf("got %d points, want 4", len(trk))
+	}
+
+	p := trk[0]
+	pointEqual(t, p, trackio.Pt(
+		time.Date(2001, 7, 16, 23, 59, 58, 0, time.UTC),
+		52+6.343/60,
+		-(6.198/60),
+	))
+	if p.Acc != 15 {
+		t.Errorf("got accuracy %v, want 15", p.Acc)
+	}
+	if !p.Ele.Valid || p.Ele.Float64 != 558 {
+		t.Errorf("got elevation %v, want GNSS altitude 558", p.Ele)
+	}
+	if !p.Speed.Valid || math.Abs(p.Speed.Float64-88/3.6) > 1e-9 {
+		t.Errorf("got speed %v, want %v", p.Speed, 88/3.6)
+	}
+	if !p.Course.Valid || p.Course.Float64 != 12 {
+		t.Errorf("got course %v, want 12", p.Course)
+	}
+
+	// 2D fix uses pressure altitude
+	if p := trk[1]; p.Lat >= 0 || !p.Ele.Valid || p.Ele.Float64 != 593 {
+		t.Errorf("got lat %v elevation %v, want southern hemisphere and pressure altitude 593", p.Lat, p.Ele)
+	}
+
+	// midnight rollover
+	want := time.Date(2001, 7, 17, 0, 0, 1, 0, time.UTC)
+	if !trk[2].Time.Equal(want) {
+		t.Errorf("got time %v, want %v", trk[2].Time, want)
+	}
+	if trk[2].Ele.Valid {
+		t.Errorf("got elevation %v, want invalid", trk[2].Ele)
+	}
+	if e := trk[3].Ele; !e.Valid || e.Float64 != -12 {
+		t.Errorf("got elevation %v, want -12", e)
+	}
+
+	md := d.Metadata()
+	if md.Name != "Bloggs Bill D" || md.Device != "Manufacturer,Model" {
+		t.Errorf("got metadata %+v", md)
+	}
+	const wantDesc = "Glider type: Schempp Ventus2\nGlider ID: D-1234"
+	if md.Description != wantDesc {
+		t.Errorf("got description %q, want %q", md.Description, wantDesc)
+	}
+}
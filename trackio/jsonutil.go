@@ -0,0 +1,77 @@
+package trackio
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// jsonObjectString returns the string value for key
+// of the JSON object at the start of p.
+//
+// It returns false if the object has no such key
+// within p, or if its value is not a string.
+func jsonObjectString(p []byte, key string) (string, bool) {
+	j := json.NewDecoder(bytes.NewReader(p))
+	if err := readTokens(j, json.Delim('{')); err != nil {
+		return "", false
+	}
+	for j.More() {
+		tok, err := j.Token()
+		if err != nil {
+			return "", false
+		}
+		if tok == key {
+			tok, err := j.Token()
+			s, ok := tok.(string)
+			return s, err == nil && ok
+		}
+		if err := skipJSONValue(j); err != nil {
+			return "", false
+		}
+	}
+	return "", false
+}
+
+// decodeJSONValue decodes the next value of j into v.
+//
+// Syntax and I/O errors are returned unchanged,
+// because j can't continue after them. Other errors,
+// such as type mismatches, are returned as a *DecodeError.
+func decodeJSONValue(j *json.Decoder, v interface{}) error {
+	var raw json.RawMessage
+	if err := j.Decode(&raw); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		if de, ok := err.(*DecodeError); ok {
+			return de
+		}
+		return newDecodeError(err)
+	}
+	return nil
+}
+
+// jsonInputPos returns the input position of j.
+func jsonInputPos(j *json.Decoder) inputPos {
+	return inputPos{offset: j.InputOffset()}
+}
+
+// skipJSONValue skips the next value in j.
+func skipJSONValue(j *json.Decoder) error {
+	depth := 0
+	for {
+		tok, err := j.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
@@ -4,17 +4,21 @@ import (
 	"encoding/xml"
 	"fmt"
 	"io"
+	"math"
+	"strconv"
+	"strings"
 	"time"
 )
 
 func init() {
 	RegisterFormat("kml", isXML("kml"), newKML)
+	RegisterEncoder("kml", newKMLWriter)
 }
 
 /* KML Format:
 
 <?xml .. ?>
-<kml>
+<kml xmlns="http://www.opengis.net/kml/2.2" xmlns:gx="http://www.google.com/kml/ext/2.2">
 	<Document>
 		<Placemark>
 			<gx:Track>
@@ -22,6 +26,18 @@ func init() {
 				<when>2017-12-21T08:44:28Z</when>
 				<gx:coord>18.2726462 46.196407699999995 0</gx:coord>
 ...
+		<Placemark>
+			<gx:MultiTrack>
+				<gx:interpolate>1</gx:interpolate>
+				<gx:Track>...</gx:Track>
+				<gx:Track>...</gx:Track>
+			</gx:MultiTrack>
+		</Placemark>
+		<Placemark>
+			<TimeSpan><begin>2017-12-21T09:00:00Z</begin><end>2017-12-21T10:00:00Z</end></TimeSpan>
+			<Point><coordinates>18.27,46.19,0</coordinates></Point>
+		</Placemark>
+...
 
 */
 
@@ -29,21 +45,33 @@ func init() {
 // «Feature»: Document|Folder|Placemark
 // Document: 0 or more «Feature» elements
 // Folder: 0 or more «Feature» elements
-// Placemark: 0 or more gx:Track elements
+// Placemark: 0 or 1 «Geometry» elements
+// «Geometry»: gx:Track|gx:MultiTrack|MultiGeometry|Point|LineString
+// MultiGeometry: 0 or more «Geometry» elements
+// gx:MultiTrack: 0 or more gx:Track elements
+//
+// Placemarks having track points are tracks.
+//
+// Each gx:Track is a separate segment, except within a gx:MultiTrack
+// having gx:interpolate set, where all gx:Tracks form a single segment.
+//
+// Point and LineString placemarks with a TimeStamp or TimeSpan
+// are decoded as track points. Point placemarks and LineStrings
+// without time information are decoded as waypoints and routes.
 
 func newKML(r io.Reader) (PointReader, error) {
 	d := xml.NewDecoder(r)
 
 	doc, err := nextStartElement(d)
 	if err != nil {
-		panic("impossible")
+		return nil, ErrFormat
 	}
 
 	if doc.Name.Local != "kml" {
-		panic("impossible")
+		return nil, ErrFormat
 	}
 
-	pr := new(kml)
+	pr := &kml{trk: -1, seg: -1}
 	pr.td = newXMLTreeDecoder(d, xmlTreeFunc(pr.kmlTreeFunc))
 	return pr, nil
 }
@@ -55,37 +83,135 @@ type kml struct {
 
 	coord strFifo
 	when  strFifo
+
+	trk, seg int // current track and segment index
+
+	pm kmlPlacemark // current Placemark
+
+	interpolate bool // current gx:MultiTrack has gx:interpolate set
+	multiTracks int  // number of gx:Tracks in the current gx:MultiTrack
+
+	pts []Point // points decoded but not yet returned
+
+	md   Metadata
+	wpts []Waypoint
+	rtes []Route
+}
+
+// kmlPlacemark holds the Placemark information
+// needed to decode its geometry.
+type kmlPlacemark struct {
+	name, desc string
+
+	hasTrk bool // trk has been allocated for this Placemark
+
+	when       string // TimeStamp
+	begin, end string // TimeSpan
+}
+
+func (k *kml) Metadata() Metadata    { return k.md }
+func (k *kml) Waypoints() []Waypoint { return k.wpts }
+func (k *kml) Routes() []Route       { return k.rtes }
+func (k *kml) inputPos() inputPos    { return k.td.inputPos() }
+
+// Namespaces of KML elements.
+const (
+	kmlNamespace   = "http://www.opengis.net/kml/"
+	kmlNamespaceGE = "http://earth.google.com/kml/"
+	kmlNamespaceGX = "http://www.google.com/kml/ext/2.2"
+)
+
+// isKMLName reports whether e is in a KML namespace.
+//
+// Elements without namespace are accepted as well,
+// along with elements having an undeclared gx prefix.
+func isKMLName(e xml.Name) bool {
+	switch {
+	case e.Space == "", e.Space == "gx", e.Space == kmlNamespaceGX:
+		return true
+	case strings.HasPrefix(e.Space, kmlNamespace), strings.HasPrefix(e.Space, kmlNamespaceGE):
+		return true
+	}
+	return false
 }
 
 func (k *kml) kmlTreeFunc(p []xml.Name, e xml.Name) xmlTreeOp {
-	// TODO: check namespace
+	if !isKMLName(e) {
+		return xmlSkip
+	}
+
+	var parent string
 	if len(p) != 0 {
-		last := p[len(p)-1]
-		if last.Local == "Track" {
-			if e.Local == "when" || e.Local == "coord" {
+		parent = p[len(p)-1].Local
+	}
+
+	switch parent {
+
+	case "", "Document", "Folder":
+		switch e.Local {
+		case "Document", "Folder":
+			return xmlEnter
+		case "Placemark":
+			k.pm = kmlPlacemark{}
+			return xmlEnter
+		case "name", "description":
+			if len(p) == 1 && parent == "Document" {
 				return xmlReturn
-			} else {
-				return xmlSkip
 			}
 		}
-		if last.Local == "Placemark" {
-			if e.Local == "Track" {
-				k.nextErr = k.checkLengthMismatch()
-				k.coord.reset()
-				k.when.reset()
-				return xmlEnter
-			} else {
-				return xmlSkip
+
+	case "Placemark", "MultiGeometry":
+		switch e.Local {
+		case "name", "description", "TimeStamp", "TimeSpan":
+			if parent == "Placemark" {
+				return xmlReturn
 			}
+		case "Point", "LineString":
+			return xmlReturn
+		case "MultiGeometry":
+			return xmlEnter
+		case "MultiTrack":
+			k.interpolate = false
+			k.multiTracks = 0
+			return xmlEnter
+		case "Track":
+			k.startTrack(false)
+			return xmlEnter
+		}
+
+	case "MultiTrack":
+		switch e.Local {
+		case "interpolate":
+			return xmlReturn
+		case "Track":
+			k.startTrack(true)
+			return xmlEnter
+		}
+
+	case "Track":
+		switch e.Local {
+		case "when", "coord":
+			return xmlReturn
 		}
 	}
-	switch e.Local {
-	case "Document", "Folder", "Placemark":
-		return xmlEnter
-	}
+
 	return xmlSkip
 }
 
+// startTrack is called at the start of a gx:Track element.
+func (k *kml) startTrack(inMulti bool) {
+	if !inMulti || !k.interpolate || k.multiTracks == 0 {
+		k.seg++
+	}
+	if inMulti {
+		k.multiTracks++
+	}
+
+	k.nextErr = k.checkLengthMismatch()
+	k.coord.reset()
+	k.when.reset()
+}
+
 func (k *kml) checkLengthMismatch() error {
 	if k.coord.n != k.when.n {
 		return decodeError("length mismatch (coord: %d, when: %d)",
@@ -106,6 +232,12 @@ func (k *kml) ReadPoint() (Point, error) {
 
 func (k *kml) readPoint() (Point, error) {
 	for {
+		if len(k.pts) != 0 {
+			p := k.pts[0]
+			k.pts = k.pts[1:]
+			return p, nil
+		}
+
 		if err := k.popErr(); err != nil {
 			return Point{}, err
 		}
@@ -115,6 +247,16 @@ func (k *kml) readPoint() (Point, error) {
 			return Point{}, k.pushErr(err)
 		}
 
+		switch se.Name.Local {
+		case "coord", "when", "name", "description", "interpolate":
+			// handled below
+		default:
+			if err := k.decodeGeometry(se); err != nil {
+				return Point{}, k.pushErr(err)
+			}
+			continue
+		}
+
 		charData, err := xmlCharData(k.td.d)
 		if err != nil {
 			return Point{}, k.pushErr(err)
@@ -125,6 +267,12 @@ func (k *kml) readPoint() (Point, error) {
 			k.coord.push(charData)
 		case "when":
 			k.when.push(charData)
+		case "name", "description":
+			k.setInfo(se.Name.Local, charData)
+			continue
+		case "interpolate":
+			k.interpolate = strings.TrimSpace(charData) == "1"
+			continue
 		default:
 			panic("impossible")
 		}
@@ -136,8 +284,7 @@ func (k *kml) readPoint() (Point, error) {
 		if k.coord.has() && k.when.has() {
 			coord, when := k.coord.pop(), k.when.pop()
 
-			var lat, long, ele float64
-			lat, long, ele, err = decodeKMLCoord(coord)
+			c, err := decodeKMLCoord(coord)
 			if err != nil {
 				return Point{}, err
 			}
@@ -147,21 +294,212 @@ func (k *kml) readPoint() (Point, error) {
 				return Point{}, decodeError("invalid timestamp %q", when)
 			}
 
-			return Point{
-				Time: ts.UTC(),
-				Lat:  lat,
-				Long: long,
+			return k.point(ts, c), nil
+		}
+	}
+}
+
+// point returns a track point of the current Placemark and segment.
+func (k *kml) point(ts time.Time, c kmlCoord) Point {
+	if !k.pm.hasTrk {
+		k.pm.hasTrk = true
+		k.trk++
+		if k.pm.name != "" || k.pm.desc != "" {
+			*k.md.track(k.trk) = TrackInfo{k.pm.name, k.pm.desc}
+		}
+	}
+
+	return Point{
+		Time: ts.UTC(),
+		Lat:  c.lat,
+		Long: c.long,
+
+		Acc: NoAccuracy,
+
+		Ele: Elevation{
+			Valid:   c.hasEle,
+			Float64: c.ele,
+			Acc:     NoAccuracy,
+		},
+
+		Trk: k.trk,
+		Seg: k.seg,
+	}
+}
+
+// decodeGeometry decodes TimeStamp, TimeSpan,
+// Point and LineString elements.
+func (k *kml) decodeGeometry(se xml.StartElement) error {
+	var v struct {
+		When        string `xml:"when"`
+		Begin       string `xml:"begin"`
+		End         string `xml:"end"`
+		Coordinates string `xml:"coordinates"`
+	}
+	if err := k.td.d.DecodeElement(&v, &se); err != nil {
+		return err
+	}
+
+	switch se.Name.Local {
+	case "TimeStamp":
+		k.pm.when = strings.TrimSpace(v.When)
+		return nil
+	case "TimeSpan":
+		k.pm.begin, k.pm.end = strings.TrimSpace(v.Begin), strings.TrimSpace(v.End)
+		return nil
+	}
+
+	coords, err := decodeKMLCoordinates(v.Coordinates)
+	if err != nil {
+		return err
+	}
+
+	times, err := k.pm.times(se.Name.Local, coords)
+	if err != nil {
+		return err
+	}
+
+	if times == nil {
+		// no time information
+		var wpts []Waypoint
+		for _, c := range coords {
+			wpts = append(wpts, Waypoint{
+				Lat:  c.lat,
+				Long: c.long,
+				Ele:  Elevation{Valid: c.hasEle, Float64: c.ele, Acc: NoAccuracy},
+			})
+		}
+		if se.Name.Local == "Point" {
+			wpts[0].Name, wpts[0].Description = k.pm.name, k.pm.desc
+			k.wpts = append(k.wpts, wpts[0])
+		} else {
+			k.rtes = append(k.rtes, Route{
+				Name:        k.pm.name,
+				Description: k.pm.desc,
+				Points:      wpts,
+			})
+		}
+		return nil
+	}
+
+	k.seg++
+	for i, t := range times {
+		c := coords[0]
+		if i < len(coords) {
+			c = coords[i]
+		}
+		k.pts = append(k.pts, k.point(t, c))
+	}
+	return nil
+}
+
+// times returns the times of the coordinates of a Point or LineString
+// within the Placemark. A Point with a TimeSpan has two
+// time stamps for its begin and end.
+//
+// It returns nil if the Placemark has no time information.
+func (pm *kmlPlacemark) times(geom string, coords []kmlCoord) ([]time.Time, error) {
+	parse := func(s string) (time.Time, error) {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return time.Time{}, decodeError("invalid timestamp %q", s)
+		}
+		return t, nil
+	}
+
+	if pm.when != "" {
+		t, err := parse(pm.when)
+		if err != nil || geom == "Point" {
+			return []time.Time{t}, err
+		}
+		// LineString with a single time stamp
+		v := make([]time.Time, len(coords))
+		for i := range v {
+			v[i] = t
+		}
+		return v, nil
+	}
+
+	if pm.begin == "" || pm.end == "" {
+		return nil, nil
+	}
+
+	begin, err := parse(pm.begin)
+	if err != nil {
+		return nil, err
+	}
+	end, err := parse(pm.end)
+	if err != nil {
+		return nil, err
+	}
+
+	if geom == "Point" {
+		return []time.Time{begin, end}, nil
+	}
+	return kmlLineTimes(coords, begin, end), nil
+}
+
+// kmlLineTimes distributes the time between begin and end
+// along coords proportionally to the distance travelled.
+func kmlLineTimes(coords []kmlCoord, begin, end time.Time) []time.Time {
+	dist := make([]float64, len(coords))
+	for i := 1; i < len(coords); i++ {
+		a, b := coords[i-1], coords[i]
+		dlat := b.lat - a.lat
+		dlong := (b.long - a.long) * math.Cos((a.lat+b.lat)/2*math.Pi/180)
+		dist[i] = dist[i-1] + math.Sqrt(dlat*dlat+dlong*dlong)
+	}
+
+	total := dist[len(dist)-1]
+	d := end.Sub(begin)
+	v := make([]time.Time, len(coords))
+	for i := range v {
+		var f float64
+		switch {
+		case total > 0:
+			f = dist[i] / total
+		case len(coords) > 1:
+			f = float64(i) / float64(len(coords)-1)
+		}
+		v[i] = begin.Add(time.Duration(f * float64(d)))
+	}
+	return v
+}
 
-				Acc: NoAccuracy,
+type kmlCoord struct {
+	lat, long, ele float64
+	hasEle         bool
+}
 
-				Ele: Elevation{
-					Valid:   true, // NOTE: unknown if elevation is truly valid
-					Float64: ele,
-					Acc:     NoAccuracy,
-				},
-			}, nil
+// decodeKMLCoordinates decodes the value of a coordinates element,
+// that holds whitespace separated longitude,latitude[,altitude] tuples.
+func decodeKMLCoordinates(s string) ([]kmlCoord, error) {
+	var v []kmlCoord
+	for _, t := range strings.Fields(s) {
+		f := strings.Split(t, ",")
+		if len(f) < 2 || len(f) > 3 {
+			return nil, decodeError("invalid coordinates %q", t)
+		}
+		var c kmlCoord
+		var err error
+		if c.long, err = strconv.ParseFloat(f[0], 64); err != nil {
+			return nil, decodeError("invalid coordinates %q", t)
+		}
+		if c.lat, err = strconv.ParseFloat(f[1], 64); err != nil {
+			return nil, decodeError("invalid coordinates %q", t)
+		}
+		if len(f) == 3 {
+			if c.ele, err = strconv.ParseFloat(f[2], 64); err != nil {
+				return nil, decodeError("invalid coordinates %q", t)
+			}
+			c.hasEle = true
 		}
+		v = append(v, c)
+	}
+	if len(v) == 0 {
+		return nil, decodeError("empty coordinates")
 	}
+	return v, nil
 }
 
 /*
@@ -194,6 +532,20 @@ func (k *kml) readPoint() (Point, error) {
 	}
 */
 
+// setInfo sets the name or description of
+// the Document or the current Placemark.
+func (k *kml) setInfo(elem, value string) {
+	name, desc := &k.md.Name, &k.md.Description
+	if k.td.path[len(k.td.path)-1].Local == "Placemark" {
+		name, desc = &k.pm.name, &k.pm.desc
+	}
+	if elem == "name" {
+		*name = value
+	} else {
+		*desc = value
+	}
+}
+
 func (k *kml) popErr() error {
 	err := k.nextErr
 	k.nextErr = nil
@@ -208,26 +560,38 @@ func (k *kml) pushErr(err error) error {
 	return err
 }
 
-func decodeKMLCoord(coord string) (lat, long, ele float64, err error) {
-	var garbage string
-	// TODO(tajtiattila): check for garbage after lat/long?
-	n, err := fmt.Sscanf(coord, "%f %f %f%s", &lat, &long, &ele, &garbage)
-
-	if n == 3 && err == io.EOF {
-		return lat, long, ele, nil
+// decodeKMLCoord decodes a gx:coord value,
+// that holds the longitude, latitude and an optional altitude in this order.
+func decodeKMLCoord(coord string) (kmlCoord, error) {
+	f := strings.Fields(coord)
+	if len(f) > 3 {
+		return kmlCoord{}, decodeError("garbage after coord %q", coord)
 	}
-
-	if n == 4 {
-		return 0, 0, 0, decodeError("garbage after coord %q", coord)
+	if len(f) < 2 {
+		return kmlCoord{}, decodeError("invalid coord %q", coord)
 	}
 
-	return 0, 0, 0, decodeError("invalid coord %q", coord)
+	var c kmlCoord
+	var err error
+	if c.long, err = strconv.ParseFloat(f[0], 64); err != nil {
+		return kmlCoord{}, decodeError("invalid coord %q", coord)
+	}
+	if c.lat, err = strconv.ParseFloat(f[1], 64); err != nil {
+		return kmlCoord{}, decodeError("invalid coord %q", coord)
+	}
+	if len(f) == 3 {
+		if c.ele, err = strconv.ParseFloat(f[2], 64); err != nil {
+			return kmlCoord{}, decodeError("invalid coord %q", coord)
+		}
+		c.hasEle = true
+	}
+	return c, nil
 }
 
 func decodeKML(r io.Reader) (Track, error) {
 	var kml kmlData
 	if err := xml.NewDecoder(r).Decode(&kml); err != nil {
-		return nil, &DecodeError{err}
+		return nil, newDecodeError(err)
 	}
 
 	var t []Point
@@ -256,23 +620,21 @@ func decodeKMLTrk(k kmlTrk) ([]Point, error) {
 			return nil, decodeError("invalid timestamp %q", when)
 		}
 
-		coord := k.Coord[i]
-		var lat, long, ele float64
-		// TODO(tajtiattila): check for garbage after lat/long?
-		if _, err := fmt.Sscanf(coord, "%f %f %f", &lat, &long, &ele); err != nil {
-			return nil, decodeError("invalid coord %q", coord)
+		c, err := decodeKMLCoord(k.Coord[i])
+		if err != nil {
+			return nil, err
 		}
 
 		t[i] = Point{
 			Time: ts.UTC(),
-			Lat:  lat,
-			Long: long,
+			Lat:  c.lat,
+			Long: c.long,
 
 			Acc: NoAccuracy,
 
 			Ele: Elevation{
-				Valid:   true,
-				Float64: ele,
+				Valid:   c.hasEle,
+				Float64: c.ele,
 				Acc:     NoAccuracy,
 			},
 		}
@@ -290,6 +652,129 @@ type kmlTrk struct {
 	Coord []string `xml:"coord"`
 }
 
+func newKMLWriter(w io.Writer) PointWriter {
+	return &kmlWriter{w: newErrWriter(w)}
+}
+
+type kmlWriter struct {
+	w *errWriter
+
+	started bool
+
+	trk, seg int // last track and segment index written
+
+	md Metadata
+
+	wpts []Waypoint
+	rtes []Route
+}
+
+func (k *kmlWriter) SetMetadata(m Metadata) { k.md = m }
+
+func (k *kmlWriter) SetWaypoints(wpts []Waypoint, rtes []Route) {
+	k.wpts, k.rtes = wpts, rtes
+}
+
+func (k *kmlWriter) start(p Point) {
+	if k.started {
+		return
+	}
+	k.started = true
+	k.trk, k.seg = p.Trk, p.Seg
+	k.w.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<kml xmlns="http://www.opengis.net/kml/2.2" xmlns:gx="http://www.google.com/kml/ext/2.2">
+  <Document>
+`)
+	k.writeInfo("    ", k.md.Name, k.md.Description)
+	k.writeWaypoints()
+	k.startPlacemark(p.Trk)
+}
+
+// writeWaypoints writes waypoints as Point Placemarks
+// and routes as LineString Placemarks without time information.
+func (k *kmlWriter) writeWaypoints() {
+	for _, w := range k.wpts {
+		k.w.WriteString("    <Placemark>\n")
+		k.writeInfo("      ", w.Name, w.Description)
+		fmt.Fprintf(k.w, "      <Point><coordinates>%s</coordinates></Point>\n", kmlCoordinates(w))
+		k.w.WriteString("    </Placemark>\n")
+	}
+	for _, r := range k.rtes {
+		k.w.WriteString("    <Placemark>\n")
+		k.writeInfo("      ", r.Name, r.Description)
+		k.w.WriteString("      <LineString><coordinates>")
+		for i, w := range r.Points {
+			if i != 0 {
+				k.w.WriteString(" ")
+			}
+			k.w.WriteString(kmlCoordinates(w))
+		}
+		k.w.WriteString("</coordinates></LineString>\n")
+		k.w.WriteString("    </Placemark>\n")
+	}
+}
+
+func kmlCoordinates(w Waypoint) string {
+	s := fmtFloat(w.Long) + "," + fmtFloat(w.Lat)
+	if w.Ele.Valid {
+		s += "," + fmtFloat(w.Ele.Float64)
+	}
+	return s
+}
+
+func (k *kmlWriter) startPlacemark(trk int) {
+	k.w.WriteString("    <Placemark>\n")
+	t := k.md.Track(trk)
+	k.writeInfo("      ", t.Name, t.Description)
+	k.w.WriteString(kmlStartTrack)
+}
+
+func (k *kmlWriter) writeInfo(indent, name, desc string) {
+	if name != "" {
+		fmt.Fprintf(k.w, "%s<name>%s</name>\n", indent, xmlEscape(name))
+	}
+	if desc != "" {
+		fmt.Fprintf(k.w, "%s<description>%s</description>\n", indent, xmlEscape(desc))
+	}
+}
+
+const (
+	kmlStartTrack = "      <gx:Track>\n        <altitudeMode>absolute</altitudeMode>\n"
+	kmlEndTrack   = "      </gx:Track>\n"
+)
+
+// WritePoint writes p as a when and gx:coord element pair.
+// KML has no accuracy information, therefore p.Acc and p.Ele.Acc are lost.
+// The altitude is omitted from gx:coord if p has no elevation.
+//
+// Tracks are written as Placemarks, and segments as gx:Track elements.
+func (k *kmlWriter) WritePoint(p Point) error {
+	k.start(p)
+
+	switch {
+	case p.Trk != k.trk:
+		k.w.WriteString(kmlEndTrack + "    </Placemark>\n")
+		k.startPlacemark(p.Trk)
+	case p.Seg != k.seg:
+		k.w.WriteString(kmlEndTrack + kmlStartTrack)
+	}
+	k.trk, k.seg = p.Trk, p.Seg
+
+	coord := fmtFloat(p.Long) + " " + fmtFloat(p.Lat)
+	if p.Ele.Valid {
+		coord += " " + fmtFloat(p.Ele.Float64)
+	}
+	fmt.Fprintf(k.w, "        <when>%s</when><gx:coord>%s</gx:coord>\n",
+		p.Time.UTC().Format(time.RFC3339Nano), coord)
+	return k.w.Err()
+}
+
+func (k *kmlWriter) Close() error {
+	k.start(Point{})
+	k.w.WriteString(kmlEndTrack + "    </Placemark>\n  </Document>\n</kml>\n")
+	return k.w.Err()
+}
+
 type strFifo struct {
 	buf  []string
 	r, w int
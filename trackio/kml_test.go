@@ -17,13 +17,13 @@ var sampleKML = `<?xml version='1.0' encoding='UTF-8'?>
 			<gx:Track>
 				<altitudeMode>clampToGround</altitudeMode>
 				<when>2011-04-26T07:54:05Z</when>
-				<gx:coord>54.7805978 9.4376219 100</gx:coord>
+				<gx:coord>9.4376219 54.7805978 100</gx:coord>
 				<when>2011-04-26T07:55:45Z</when>
-				<gx:coord>54.7793527 9.4382112 0</gx:coord>
+				<gx:coord>9.4382112 54.7793527 0</gx:coord>
 				<when>2011-04-26T07:57:22Z</when>
-				<gx:coord>54.7780257 9.4383682 0</gx:coord>
+				<gx:coord>9.4383682 54.7780257 0</gx:coord>
 				<when>2011-04-26T07:59:59Z</when>
-				<gx:coord>54.7764576 9.4373156 0</gx:coord>
+				<gx:coord>9.4373156 54.7764576 0</gx:coord>
 				<when>2011-04-26T08:01:44Z</when>
 				<when>2011-04-26T08:02:17Z</when>
 				<when>2011-04-26T08:06:26Z</when>
@@ -45,35 +45,35 @@ var sampleKML = `<?xml version='1.0' encoding='UTF-8'?>
 				<when>2011-04-26T08:23:13Z</when>
 				<when>2011-04-26T08:24:25Z</when>
 				<when>2011-04-26T08:25:51Z</when>
-				<gx:coord>54.7751258 9.4364726 156</gx:coord>
-				<gx:coord>54.7750663 9.4359833 156</gx:coord>
-				<gx:coord>54.7749039 9.4356500 156</gx:coord>
-				<gx:coord>54.7748850 9.4355465 156</gx:coord>
-				<gx:coord>54.7749339 9.4355597 156</gx:coord>
-				<gx:coord>54.7750490 9.4355169 156</gx:coord>
-				<gx:coord>54.7750379 9.4353671 156</gx:coord>
-				<gx:coord>54.7748416 9.4353480 156</gx:coord>
-				<gx:coord>54.7737402 9.4354813 156</gx:coord>
-				<gx:coord>54.7735581 9.4357957 156</gx:coord>
+				<gx:coord>9.4364726 54.7751258 156</gx:coord>
+				<gx:coord>9.4359833 54.7750663 156</gx:coord>
+				<gx:coord>9.4356500 54.7749039 156</gx:coord>
+				<gx:coord>9.4355465 54.7748850 156</gx:coord>
+				<gx:coord>9.4355597 54.7749339 156</gx:coord>
+				<gx:coord>9.4355169 54.7750490 156</gx:coord>
+				<gx:coord>9.4353671 54.7750379 156</gx:coord>
+				<gx:coord>9.4353480 54.7748416 156</gx:coord>
+				<gx:coord>9.4354813 54.7737402 156</gx:coord>
+				<gx:coord>9.4357957 54.7735581 156</gx:coord>
 				<when>2011-04-26T08:14:42Z</when>
 				<when>2011-04-26T08:15:08Z</when>
 				<when>2011-04-26T08:15:26Z</when>
 				<when>2011-04-26T08:16:34Z</when>
-				<gx:coord>54.7735632 9.4358950 156</gx:coord>
-				<gx:coord>54.7731582 9.4380101 156</gx:coord>
-				<gx:coord>54.7726949 9.4391077 156</gx:coord>
-				<gx:coord>54.7725256 9.4393897 156</gx:coord>
-				<gx:coord>54.7719606 9.4400140 156</gx:coord>
-				<gx:coord>54.7719509 9.4400266 156</gx:coord>
-				<gx:coord>54.7718998 9.4400669 156</gx:coord>
-				<gx:coord>54.7718369 9.4401513 156</gx:coord>
-				<gx:coord>54.7712847 9.4412500 156</gx:coord>
-				<gx:coord>54.7714076 9.4429594 156</gx:coord>
-				<gx:coord>54.7709788 9.4444952 156</gx:coord>
-				<gx:coord>54.7696980 9.4451720 156</gx:coord>
-				<gx:coord>54.7683050 9.4458231 156</gx:coord>
-				<gx:coord>54.7673059 9.4460718 156</gx:coord>
-				<gx:coord>54.7665063 9.4447323 156</gx:coord>
+				<gx:coord>9.4358950 54.7735632 156</gx:coord>
+				<gx:coord>9.4380101 54.7731582 156</gx:coord>
+				<gx:coord>9.4391077 54.7726949 156</gx:coord>
+				<gx:coord>9.4393897 54.7725256 156</gx:coord>
+				<gx:coord>9.4400140 54.7719606 156</gx:coord>
+				<gx:coord>9.4400266 54.7719509 156</gx:coord>
+				<gx:coord>9.4400669 54.7718998 156</gx:coord>
+				<gx:coord>9.4401513 54.7718369 156</gx:coord>
+				<gx:coord>9.4412500 54.7712847 156</gx:coord>
+				<gx:coord>9.4429594 54.7714076 156</gx:coord>
+				<gx:coord>9.4444952 54.7709788 156</gx:coord>
+				<gx:coord>9.4451720 54.7696980 156</gx:coord>
+				<gx:coord>9.4458231 54.7683050 156</gx:coord>
+				<gx:coord>9.4460718 54.7673059 156</gx:coord>
+				<gx:coord>9.4447323 54.7665063 156</gx:coord>
 			</gx:Track>
 		</Placemark>
 	</Document>
@@ -106,13 +106,13 @@ var sampleKMLLengthMismatch = `<?xml version='1.0' encoding='UTF-8'?>
 			<gx:Track>
 				<altitudeMode>clampToGround</altitudeMode>
 				<when>2011-04-26T07:54:05Z</when>
-				<gx:coord>54.7805978 9.4376219 100</gx:coord>
+				<gx:coord>9.4376219 54.7805978 100</gx:coord>
 				<when>2011-04-26T07:55:45Z</when>
-				<gx:coord>54.7793527 9.4382112 0</gx:coord>
+				<gx:coord>9.4382112 54.7793527 0</gx:coord>
 				<when>2011-04-26T07:57:22Z</when>
-				<gx:coord>54.7780257 9.4383682 0</gx:coord>
+				<gx:coord>9.4383682 54.7780257 0</gx:coord>
 				<when>2011-04-26T07:59:59Z</when>
-				<gx:coord>54.7764576 9.4373156 0</gx:coord>
+				<gx:coord>9.4373156 54.7764576 0</gx:coord>
 				<when>2011-04-26T08:01:44Z</when>
 				<when>2011-04-26T08:02:17Z</when>
 				<when>2011-04-26T08:06:26Z</when>
@@ -135,3 +135,154 @@ func TestKMLLengthMismatch(t *testing.T) {
 		t.Fatalf("got %v, want length mismatch error", err)
 	}
 }
+
+var sampleKMLGeometry = `<?xml version='1.0' encoding='UTF-8'?>
+<kml xmlns='http://www.opengis.net/kml/2.2' xmlns:gx='http://www.google.com/kml/ext/2.2'
+	xmlns:foo='http://example.com/foo'>
+	<Document>
+		<Placemark>
+			<name>Multi</name>
+			<gx:MultiTrack>
+				<gx:interpolate>1</gx:interpolate>
+				<gx:Track>
+					<when>2018-01-01T10:00:00Z</when>
+					<gx:coord>19.0 47.0 100</gx:coord>
+				</gx:Track>
+				<gx:Track>
+					<when>2018-01-01T10:01:00Z</when>
+					<gx:coord>19.1 47.1 100</gx:coord>
+				</gx:Track>
+			</gx:MultiTrack>
+		</Placemark>
+		<Placemark>
+			<gx:MultiTrack>
+				<gx:Track>
+					<when>2018-01-01T11:00:00Z</when>
+					<gx:coord>19.2 47.2 100</gx:coord>
+				</gx:Track>
+				<gx:Track>
+					<when>2018-01-01T11:01:00Z</when>
+					<gx:coord>19.3 47.3 100</gx:coord>
+				</gx:Track>
+			</gx:MultiTrack>
+		</Placemark>
+		<Placemark>
+			<name>Home</name>
+			<Point><coordinates>19.5,47.5,120</coordinates></Point>
+		</Placemark>
+		<Folder>
+			<Placemark>
+				<TimeStamp><when>2018-01-01T12:00:00Z</when></TimeStamp>
+				<Point><coordinates>19.4,47.4</coordinates></Point>
+			</Placemark>
+			<Placemark>
+				<TimeSpan><begin>2018-01-01T13:00:00Z</begin><end>2018-01-01T14:00:00Z</end></TimeSpan>
+				<Point><coordinates>19.6,47.6</coordinates></Point>
+			</Placemark>
+			<Placemark>
+				<TimeSpan><begin>2018-01-01T15:00:00Z</begin><end>2018-01-01T15:30:00Z</end></TimeSpan>
+				<LineString><coordinates>19.0,47.0 19.0,47.1 19.0,47.3</coordinates></LineString>
+			</Placemark>
+			<Placemark>
+				<name>Route</name>
+				<LineString><coordinates>19.0,47.0 19.1,47.1</coordinates></LineString>
+			</Placemark>
+			<foo:Placemark>
+				<TimeStamp><when>2018-01-01T16:00:00Z</when></TimeStamp>
+				<Point><coordinates>1,1</coordinates></Point>
+			</foo:Placemark>
+		</Folder>
+	</Document>
+</kml>`
+
+func TestKMLGeometry(t *testing.T) {
+	d := trackio.NewDecoder(strings.NewReader(sampleKMLGeometry))
+	trk, err := d.Track()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tm := func(h, m int) time.Time {
+		return time.Date(2018, 1, 1, h, m, 0, 0, time.UTC)
+	}
+	want := []struct {
+		t         time.Time
+		lat, long float64
+		trk, seg  int
+	}{
+		{tm(10, 0), 47.0, 19.0, 0, 0},
+		{tm(10, 1), 47.1, 19.1, 0, 0}, // interpolated MultiTrack
+		{tm(11, 0), 47.2, 19.2, 1, 1},
+		{tm(11, 1), 47.3, 19.3, 1, 2},
+		{tm(12, 0), 47.4, 19.4, 2, 3}, // TimeStamp
+		{tm(13, 0), 47.6, 19.6, 3, 4}, // TimeSpan
+		{tm(14, 0), 47.6, 19.6, 3, 4},
+		{tm(15, 0), 47.0, 19.0, 4, 5}, // LineString with TimeSpan
+		{tm(15, 10), 47.1, 19.0, 4, 5},
+		{tm(15, 30), 47.3, 19.0, 4, 5},
+	}
+	if len(trk) != len(want) {
+		t.Fatalf("got %d points, want %d", len(trk), len(want))
+	}
+	for i, w := range want {
+		p := trk[i]
+		if !p.Time.Equal(w.t) || p.Lat != w.lat || p.Long != w.long || p.Trk != w.trk || p.Seg != w.seg {
+			t.Errorf("point %d: got %v %v,%v trk=%d seg=%d, want %v %v,%v trk=%d seg=%d",
+				i, p.Time, p.Lat, p.Long, p.Trk, p.Seg, w.t, w.lat, w.long, w.trk, w.seg)
+		}
+	}
+
+	md := d.Metadata()
+	if n := md.Track(0).Name; n != "Multi" {
+		t.Errorf("got track name %q, want %q", n, "Multi")
+	}
+
+	wpts := d.Waypoints()
+	if len(wpts) != 1 || wpts[0].Name != "Home" || wpts[0].Lat != 47.5 || wpts[0].Ele.Float64 != 120 {
+		t.Errorf("got waypoints %+v", wpts)
+	}
+	rtes := d.Routes()
+	if len(rtes) != 1 || rtes[0].Name != "Route" || len(rtes[0].Points) != 2 {
+		t.Errorf("got routes %+v", rtes)
+	}
+}
+
+func TestKMLWaypoints(t *testing.T) {
+	wpts := []trackio.Waypoint{
+		{Lat: 47.5, Long: 19.05, Name: "Home"},
+	}
+	rtes := []trackio.Route{{
+		Name: "Route",
+		Points: []trackio.Waypoint{
+			{Lat: 47.5, Long: 19.05},
+			{Lat: 47.6, Long: 19.1},
+		},
+	}}
+	trk := trackio.Track{
+		trackio.Pt(time.Date(2018, 1, 1, 10, 0, 0, 0, time.UTC), 47.5, 19.05),
+	}
+
+	buf := new(bytes.Buffer)
+	e := trackio.NewEncoder(buf, "kml")
+	e.SetWaypoints(wpts, rtes)
+	if err := e.Encode(trk); err != nil {
+		t.Fatal(err)
+	}
+
+	d := trackio.NewDecoder(buf)
+	got, err := d.Track()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Trk != 0 || got[0].Seg != 0 {
+		t.Errorf("got track %+v", got)
+	}
+	gw := d.Waypoints()
+	if len(gw) != 1 || gw[0].Name != "Home" || gw[0].Lat != 47.5 || gw[0].Long != 19.05 {
+		t.Errorf("got waypoints %+v", gw)
+	}
+	gr := d.Routes()
+	if len(gr) != 1 || gr[0].Name != "Route" || len(gr[0].Points) != 2 || gr[0].Points[1].Lat != 47.6 {
+		t.Errorf("got routes %+v", gr)
+	}
+}
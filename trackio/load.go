@@ -0,0 +1,126 @@
+package trackio
+
+import (
+	"context"
+	"io"
+	"runtime"
+	"sync"
+
+	"github.com/tajtiattila/track"
+)
+
+// Loader decodes multiple track files concurrently
+// and merges them into a single track.
+type Loader struct {
+	// Workers is the maximum number of files decoded concurrently.
+	// If Workers <= 0, runtime.GOMAXPROCS(0) is used.
+	Workers int
+
+	// Open returns the decoder for path, and the Closer
+	// to be closed after decoding. If Open is nil, OpenFile is used.
+	//
+	// Open is called from multiple goroutines simultaneously.
+	Open func(path string) (*Decoder, io.Closer, error)
+
+	// Policy is used to merge the tracks of files
+	// overlapping with earlier ones.
+	Policy track.MergePolicy
+}
+
+// FileError records an error loading a file.
+type FileError struct {
+	Path string
+	Err  error
+}
+
+func (e *FileError) Error() string { return e.Path + ": " + e.Err.Error() }
+
+// Unwrap returns the underlying error.
+func (e *FileError) Unwrap() error { return e.Err }
+
+// OpenFile returns a decoder for the track file path
+// using DefaultRegistry.
+//
+// If path is a directory, the first directory format registered
+// with RegisterDir that recognises it is used, such as the track
+// stores of package trackstore when that package is imported.
+// Other directories are read with NewPhotoDecoder.
+func OpenFile(path string) (*Decoder, io.Closer, error) {
+	return DefaultRegistry.OpenFile(path)
+}
+
+// Load decodes the files in paths and merges them into
+// a single track in chronological order.
+//
+// Files are merged in the order of paths, regardless of
+// the order decoding finishes, using l.Policy.
+// With the default policy, the points of a file replace
+// the points of earlier files within its time range.
+//
+// Files failing to load are skipped, and their errors
+// are returned in the order of paths. If ctx is canceled,
+// the files not yet loaded fail with ctx.Err().
+func (l *Loader) Load(ctx context.Context, paths []string) (track.Track, []*FileError) {
+	nworker := l.Workers
+	if nworker <= 0 {
+		nworker = runtime.GOMAXPROCS(0)
+	}
+	if nworker > len(paths) {
+		nworker = len(paths)
+	}
+
+	type result struct {
+		trk Track
+		err error
+	}
+	results := make([]result, len(paths))
+
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < nworker; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				trk, err := l.load(ctx, paths[i])
+				results[i] = result{trk, err}
+			}
+		}()
+	}
+
+	for i := range paths {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+
+	var trk track.Track
+	var errs []*FileError
+	for i, r := range results {
+		if r.err != nil {
+			errs = append(errs, &FileError{Path: paths[i], Err: r.err})
+			continue
+		}
+		trk.MergeWith(r.trk.Compact(), l.Policy)
+	}
+	return trk, errs
+}
+
+func (l *Loader) load(ctx context.Context, path string) (Track, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	open := l.Open
+	if open == nil {
+		open = OpenFile
+	}
+
+	d, closer, err := open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+
+	return d.TrackContext(ctx)
+}
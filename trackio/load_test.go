@@ -0,0 +1,92 @@
+package trackio_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tajtiattila/track/trackio"
+)
+
+func TestLoader(t *testing.T) {
+	dir := t.TempDir()
+
+	epoch := time.Date(2018, 1, 1, 10, 0, 0, 0, time.UTC)
+	writeCSV := func(name string, start, n int, lat float64) string {
+		var sb strings.Builder
+		sb.WriteString("time,lat,lon\n")
+		for i := start; i < start+n; i++ {
+			ts := epoch.Add(time.Duration(i) * time.Minute)
+			fmt.Fprintf(&sb, "%s,%v,19\n", ts.Format(time.RFC3339), lat)
+		}
+		fn := filepath.Join(dir, name)
+		if err := os.WriteFile(fn, []byte(sb.String()), 0666); err != nil {
+			t.Fatal(err)
+		}
+		return fn
+	}
+
+	bad := filepath.Join(dir, "bad.txt")
+	if err := os.WriteFile(bad, []byte("not a track"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	paths := []string{
+		writeCSV("a.csv", 0, 10, 47),
+		filepath.Join(dir, "missing.csv"),
+		writeCSV("b.csv", 5, 10, 48),
+		bad,
+		writeCSV("c.csv", 30, 5, 49),
+	}
+
+	l := &trackio.Loader{
+		Workers: 2,
+		Open: func(path string) (*trackio.Decoder, io.Closer, error) {
+			d, c, err := trackio.OpenFile(path)
+			if d != nil {
+				d.Accuracy = trackio.NoAccuracy
+			}
+			return d, c, err
+		},
+	}
+	trk, errs := l.Load(context.Background(), paths)
+
+	if len(errs) != 2 || errs[0].Path != paths[1] || errs[1].Path != paths[3] {
+		t.Fatalf("got errors %v", errs)
+	}
+	if errs[1].Err != trackio.ErrFormat {
+		t.Fatalf("got error %v, want ErrFormat", errs[1].Err)
+	}
+
+	// a.csv up to 10:05, then b.csv and c.csv
+	if len(trk) != 5+10+5 {
+		t.Fatalf("got %d points, want 20", len(trk))
+	}
+	for i, p := range trk {
+		want := 47.0
+		switch {
+		case i >= 15:
+			want = 49
+		case i >= 5:
+			want = 48
+		}
+		if p.Lat() != want {
+			t.Fatalf("point %d at %v: got lat %v, want %v", i, p.Time(), p.Lat(), want)
+		}
+		if i > 0 && p.Time().Before(trk[i-1].Time()) {
+			t.Fatalf("point %d out of order", i)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	trk, errs = l.Load(ctx, paths)
+	if len(trk) != 0 || len(errs) != len(paths) || errs[0].Err != context.Canceled {
+		t.Fatalf("got %d points and errors %v after cancel", len(trk), errs)
+	}
+}
@@ -0,0 +1,97 @@
+package trackio
+
+// Metadata holds descriptive information of a track file.
+type Metadata struct {
+	Name        string // name of the file
+	Description string // description of the file
+
+	Creator string // application that created the file
+	Device  string // name or id of the recording device
+
+	// Tracks holds information of the individual tracks
+	// indexed by Point.Trk.
+	Tracks []TrackInfo
+}
+
+// TrackInfo holds descriptive information of a single track.
+type TrackInfo struct {
+	Name        string
+	Description string
+}
+
+// IsZero reports whether m holds no information.
+func (m *Metadata) IsZero() bool {
+	if m.Name != "" || m.Description != "" || m.Creator != "" || m.Device != "" {
+		return false
+	}
+	for _, t := range m.Tracks {
+		if t != (TrackInfo{}) {
+			return false
+		}
+	}
+	return true
+}
+
+// Track returns the TrackInfo for the track index i.
+// It returns the zero TrackInfo if m has no information for track i.
+func (m *Metadata) Track(i int) TrackInfo {
+	if 0 <= i && i < len(m.Tracks) {
+		return m.Tracks[i]
+	}
+	return TrackInfo{}
+}
+
+// track returns the TrackInfo for track index i,
+// growing m.Tracks as needed.
+func (m *Metadata) track(i int) *TrackInfo {
+	if i < 0 {
+		i = 0
+	}
+	for len(m.Tracks) <= i {
+		m.Tracks = append(m.Tracks, TrackInfo{})
+	}
+	return &m.Tracks[i]
+}
+
+// MetadataReader is implemented by PointReaders
+// that decode track file metadata.
+type MetadataReader interface {
+	// Metadata returns the metadata decoded so far.
+	//
+	// Metadata may appear anywhere within the input,
+	// therefore it is complete only after
+	// all points have been read.
+	Metadata() Metadata
+}
+
+// MetadataWriter is implemented by PointWriters
+// that encode track file metadata.
+type MetadataWriter interface {
+	// SetMetadata sets the metadata to be written.
+	// It must be called before the first call to WritePoint.
+	SetMetadata(m Metadata)
+}
+
+// Metadata returns the metadata decoded so far
+// by the underlying PointReader.
+//
+// It returns the zero Metadata if the format
+// has no metadata support.
+func (d *Decoder) Metadata() Metadata {
+	if mr, ok := d.PointReader.(MetadataReader); ok {
+		return mr.Metadata()
+	}
+	return Metadata{}
+}
+
+// SetMetadata sets the metadata to be written
+// by the underlying PointWriter.
+//
+// It must be called before the first point is written.
+// Metadata is silently ignored if the format
+// has no metadata support.
+func (e *Encoder) SetMetadata(m Metadata) {
+	if mw, ok := e.PointWriter.(MetadataWriter); ok {
+		mw.SetMetadata(m)
+	}
+}
@@ -0,0 +1,297 @@
+package trackio
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	RegisterFormat("nmea", isNMEA, newNMEA)
+}
+
+/* NMEA 0183 Format:
+
+$GPGGA,123519.00,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*40
+$GPGSA,A,3,04,05,,09,12,,,24,,,,,2.5,1.3,2.1*39
+$GPRMC,123519.00,A,4807.038,N,01131.000,E,022.4,084.4,230394,003.1,W*6A
+
+Each line is a sentence starting with '$',
+followed by the talker id (such as GP or GN), the sentence type
+and comma separated fields, optionally followed by '*' and a checksum.
+
+Track points are assembled from sentences having the same time of day.
+The date is taken from RMC sentences, the position from RMC or GGA,
+the altitude from GGA and dilution of precision values from GGA and GSA.
+
+see http://www.catb.org/gpsd/NMEA.html
+
+*/
+
+func isNMEA(p []byte) bool {
+	p = bytes.TrimLeft(p, " \t\r\n")
+	if len(p) < 7 || p[0] != '$' || p[6] != ',' {
+		return false
+	}
+	for _, c := range p[1:6] {
+		if !('A' <= c && c <= 'Z' || '0' <= c && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+func newNMEA(r io.Reader) (PointReader, error) {
+	return &nmea{s: newLineScanner(r)}, nil
+}
+
+type nmea struct {
+	s *lineScanner
+
+	fix nmeaFix // fix being assembled
+
+	date    time.Time // date of the last RMC sentence
+	hasDate bool
+
+	nextErr error
+}
+
+// nmeaFix holds data of sentences having the same time of day.
+type nmeaFix struct {
+	tod     time.Duration // time of day
+	hasTime bool
+
+	date    time.Time
+	hasDate bool
+
+	lat, long float64
+	hasPos    bool
+
+	ele    float64
+	hasEle bool
+
+	hdop, vdop string
+}
+
+func (n *nmea) inputPos() inputPos { return n.s.inputPos() }
+
+func (n *nmea) ReadPoint() (Point, error) {
+	if err := n.nextErr; err != nil {
+		n.nextErr = nil
+		return Point{}, err
+	}
+
+	for n.s.Scan() {
+		line := strings.TrimSpace(n.s.Text())
+		if line == "" {
+			continue
+		}
+
+		f, err := nmeaFields(line)
+		if err != nil {
+			return Point{}, err
+		}
+
+		pt, ok, err := n.sentence(f)
+		if ok || err != nil {
+			return pt, err
+		}
+	}
+
+	if err := n.s.Err(); err != nil {
+		return Point{}, err
+	}
+
+	pt, ok, err := n.flush()
+	if ok || err != nil {
+		return pt, err
+	}
+	return Point{}, io.EOF
+}
+
+// nmeaFields validates the checksum of line,
+// and returns its comma separated fields.
+func nmeaFields(line string) ([]string, error) {
+	if line[0] != '$' {
+		return nil, decodeError("invalid nmea sentence %q", line)
+	}
+
+	body := line[1:]
+	if i := strings.LastIndexByte(body, '*'); i >= 0 {
+		want, err := strconv.ParseUint(body[i+1:], 16, 8)
+		if err != nil {
+			return nil, decodeError("invalid nmea checksum in %q", line)
+		}
+		body = body[:i]
+
+		var sum byte
+		for i := 0; i < len(body); i++ {
+			sum ^= body[i]
+		}
+		if sum != byte(want) {
+			return nil, decodeError("nmea checksum mismatch in %q", line)
+		}
+	}
+
+	f := strings.Split(body, ",")
+	if len(f[0]) != 5 {
+		return nil, decodeError("invalid nmea sentence %q", line)
+	}
+	return f, nil
+}
+
+// sentence processes the sentence having fields f.
+//
+// It returns ok == true if the sentence completed a track point.
+func (n *nmea) sentence(f []string) (pt Point, ok bool, err error) {
+	pt, ok, err = n.parse(f)
+	if ok && err != nil {
+		// report err with the next call to ReadPoint
+		n.nextErr = err
+		err = nil
+	}
+	return pt, ok, err
+}
+
+func (n *nmea) parse(f []string) (pt Point, ok bool, err error) {
+	switch f[0][2:] {
+	case "RMC":
+		// $GPRMC,hhmmss.ss,A,llll.ll,a,yyyyy.yy,a,x.x,x.x,ddmmyy,x.x,a
+		if len(f) < 10 {
+			return Point{}, false, decodeError("short nmea RMC sentence")
+		}
+		pt, ok, err = n.startFix(f[1])
+		if err != nil {
+			return pt, ok, err
+		}
+		date, err := time.Parse("020106", f[9])
+		if err != nil {
+			return pt, ok, decodeError("invalid nmea date %q", f[9])
+		}
+		n.fix.date, n.fix.hasDate = date, true
+		n.date, n.hasDate = date, true
+		if f[2] == "A" {
+			n.fix.setPos(f[3:7])
+		}
+
+	case "GGA":
+		// $GPGGA,hhmmss.ss,llll.ll,a,yyyyy.yy,a,q,nn,h.h,a.a,M,g.g,M,x.x,xxxx
+		if len(f) < 11 {
+			return Point{}, false, decodeError("short nmea GGA sentence")
+		}
+		pt, ok, err = n.startFix(f[1])
+		if err != nil {
+			return pt, ok, err
+		}
+		if f[6] != "" && f[6] != "0" {
+			n.fix.setPos(f[2:6])
+			n.fix.hdop = f[8]
+			if v, err := strconv.ParseFloat(f[9], 64); err == nil {
+				n.fix.ele, n.fix.hasEle = v, true
+			}
+		}
+
+	case "GSA":
+		// $GPGSA,a,m,xx,xx,xx,xx,xx,xx,xx,xx,xx,xx,xx,xx,p.p,h.h,v.v
+		if len(f) < 18 {
+			return Point{}, false, decodeError("short nmea GSA sentence")
+		}
+		if f[2] == "2" || f[2] == "3" {
+			n.fix.hdop, n.fix.vdop = f[16], f[17]
+		}
+	}
+
+	return pt, ok, nil
+}
+
+// startFix starts a new fix if the time of day
+// of the current sentence differs from that of the current fix.
+//
+// It returns ok == true if the previous fix yielded a track point.
+func (n *nmea) startFix(hms string) (pt Point, ok bool, err error) {
+	tod, err := nmeaTime(hms)
+	if err != nil {
+		return Point{}, false, err
+	}
+
+	if n.fix.hasTime && n.fix.tod == tod {
+		return Point{}, false, nil
+	}
+
+	pt, ok, err = n.flush()
+	n.fix = nmeaFix{tod: tod, hasTime: true}
+	return pt, ok, err
+}
+
+// flush returns the track point of the current fix.
+func (n *nmea) flush() (pt Point, ok bool, err error) {
+	fix := n.fix
+	n.fix = nmeaFix{}
+
+	if !fix.hasTime || !fix.hasPos {
+		return Point{}, false, nil
+	}
+
+	if !fix.hasDate {
+		if !n.hasDate {
+			return Point{}, false, decodeError("nmea fix without date")
+		}
+		fix.date = n.date
+	}
+
+	g := gpxPt{HDOP: fix.hdop, VDOP: fix.vdop}
+
+	pt = Pt(fix.date.Add(fix.tod), fix.lat, fix.long)
+	pt.Acc = gpsAccuracy(&g, true)
+	if fix.hasEle {
+		pt.Ele.Valid = true
+		pt.Ele.Float64 = fix.ele
+		pt.Ele.Acc = gpsAccuracy(&g, false)
+	}
+	return pt, true, nil
+}
+
+// setPos sets the position of fix from the fields
+// latitude, N/S, longitude, E/W.
+func (fix *nmeaFix) setPos(f []string) {
+	lat, ok0 := nmeaCoord(f[0], f[1], "N", "S")
+	long, ok1 := nmeaCoord(f[2], f[3], "E", "W")
+	if ok0 && ok1 {
+		fix.lat, fix.long, fix.hasPos = lat, long, true
+	}
+}
+
+// nmeaCoord parses a coordinate in the form dddmm.mmmm.
+func nmeaCoord(v, hemi, pos, neg string) (float64, bool) {
+	x, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, false
+	}
+	deg := float64(int(x / 100))
+	x = deg + (x-deg*100)/60
+	switch hemi {
+	case pos:
+		return x, true
+	case neg:
+		return -x, true
+	}
+	return 0, false
+}
+
+// nmeaTime parses a time of day in the form hhmmss.ss.
+func nmeaTime(s string) (time.Duration, error) {
+	if len(s) < 6 {
+		return 0, decodeError("invalid nmea time %q", s)
+	}
+	h, err0 := strconv.Atoi(s[0:2])
+	m, err1 := strconv.Atoi(s[2:4])
+	sec, err2 := strconv.ParseFloat(s[4:], 64)
+	if err0 != nil || err1 != nil || err2 != nil {
+		return 0, decodeError("invalid nmea time %q", s)
+	}
+	return time.Duration(h)*time.Hour +
+		time.Duration(m)*time.Minute +
+		time.Duration(sec*1e3+0.5)*time.Millisecond, nil
+}
@@ -0,0 +1,104 @@
+package trackio_test
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tajtiattila/track/trackio"
+)
+
+// nmeaLog returns sentences with checksums added.
+func nmeaLog(sentences ...string) string {
+	buf := new(bytes.Buffer)
+	for _, s := range sentences {
+		var sum byte
+		for i := 0; i < len(s); i++ {
+			sum ^= s[i]
+		}
+		fmt.Fprintf(buf, "$%s*%02X\r\n", s, sum)
+	}
+	return buf.String()
+}
+
+func TestNMEA(t *testing.T) {
+	src := nmeaLog(
+		"GPGGA,235958.00,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,",
+		"GPGSA,A,3,04,05,,09,12,,,24,,,,,2.5,1.2,2.1",
+		"GPRMC,235958.00,A,4807.038,N,01131.000,E,022.4,084.4,230394,003.1,W",
+		"GPGGA,235959.00,4807.040,N,01131.010,E,1,08,1.0,546.0,M,46.9,M,,",
+		"GPRMC,235959.00,A,4807.040,N,01131.010,E,022.4,084.4,230394,003.1,W",
+		"GPGGA,000000.00,4807.042,N,01131.020,E,0,00,,,M,,M,,", // no fix
+		"GPRMC,000000.00,V,,,,,,,240394,003.1,W",
+		"GPGGA,000001.00,3351.000,S,15112.000,W,1,08,2.0,10.0,M,46.9,M,,",
+		"GPRMC,000001.00,A,3351.000,S,15112.000,W,022.4,084.4,240394,003.1,W",
+	)
+
+	// corrupt checksum of the 4th sentence
+	lines := strings.SplitAfter(src, "\n")
+	lines[3] = strings.Replace(lines[3], "546.0", "547.0", 1)
+	src = strings.Join(lines, "")
+
+	if f, ok := trackio.DetectFormat([]byte(src)); !ok || f != "nmea" {
+		t.Fatalf("detected format %q", f)
+	}
+
+	d := trackio.NewDecoder(strings.NewReader(src))
+	d.Accuracy = trackio.NoAccuracy
+	var errs []*trackio.DecodeError
+	d.HandleDecodeError = func(e *trackio.DecodeError) error {
+		errs = append(errs, e)
+		return nil
+	}
+	trk, err := d.Track()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(errs) != 1 {
+		t.Fatalf("got %d decode errors, want 1", len(errs))
+	}
+	badOffset := int64(len(lines[0]) + len(lines[1]) + len(lines[2]))
+	if e := errs[0]; e.Offset != badOffset || e.Line != 4 || e.Column != 1 {
+		t.Errorf("got error position %q, want offset %d, line 4, column 1",
+			e.Position(), badOffset)
+	}
+
+	const wantLen = 3
+	if len(trk) != wantLen {
+		t.Fatalf("track length mismatch: want %d got %d", wantLen, len(trk))
+	}
+
+	const eps = 1e-9
+	near := func(a, b float64) bool { return math.Abs(a-b) < eps }
+
+	want := []struct {
+		t         time.Time
+		lat, long float64
+		acc, ele  float64
+	}{
+		{time.Date(1994, 3, 23, 23, 59, 58, 0, time.UTC), 48 + 7.038/60, 11 + 31.0/60, 6, 545.4},
+		{time.Date(1994, 3, 23, 23, 59, 59, 0, time.UTC), 48 + 7.04/60, 11 + 31.01/60, 5, 0},
+		{time.Date(1994, 3, 24, 0, 0, 1, 0, time.UTC), -(33 + 51.0/60), -(151 + 12.0/60), 10, 10},
+	}
+	for i, w := range want {
+		p := trk[i]
+		if !p.Time.Equal(w.t) || !near(p.Lat, w.lat) || !near(p.Long, w.long) {
+			t.Errorf("point %d: got %v %v,%v want %v %v,%v", i,
+				p.Time, p.Lat, p.Long, w.t, w.lat, w.long)
+		}
+		if !near(p.Acc, w.acc) {
+			t.Errorf("point %d: got accuracy %v, want %v", i, p.Acc, w.acc)
+		}
+		if p.Ele.Valid != (w.ele != 0) || p.Ele.Float64 != w.ele {
+			t.Errorf("point %d: got elevation %+v, want %v", i, p.Ele, w.ele)
+		}
+	}
+
+	if acc := trk[0].Ele.Acc; !near(acc, 10.5) {
+		t.Errorf("got vertical accuracy %v, want 10.5", acc)
+	}
+}
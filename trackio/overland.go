@@ -0,0 +1,159 @@
+package trackio
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+func init() {
+	RegisterFormat("overland", isOverland, newOverland)
+}
+
+/* Overland GeoJSON batch format:
+
+{"locations": [ {
+    "type": "Feature",
+    "geometry": { "type": "Point", "coordinates": [ -122.030581, 37.331800 ] },
+    "properties": {
+      "timestamp": "2017-01-01T10:00:00-0700",
+      "altitude": 80,
+      "speed": 4,
+      "course": 270,
+      "horizontal_accuracy": 30,
+      "vertical_accuracy": -1,
+      "motion": [ "driving", "stationary" ],
+      "battery_level": 0.89,
+      "device_id": "phone"
+    }
+  }, ...
+]}
+
+Speed is in meters per second, and battery_level is between 0 and 1.
+Negative accuracy, speed and course values mean the value is invalid.
+Motion types are decoded as Point.Activity, and the device_id
+as Metadata.Device.
+
+see https://github.com/aaronpk/Overland-iOS
+
+*/
+
+func isOverland(p []byte) bool {
+	j := json.NewDecoder(bytes.NewReader(p))
+	if err := readTokens(j, json.Delim('{'), "locations", json.Delim('['), json.Delim('{')); err != nil {
+		return false
+	}
+	for j.More() {
+		tok, err := j.Token()
+		if err != nil {
+			return false
+		}
+		if tok == "type" {
+			tok, err := j.Token()
+			return err == nil && tok == "Feature"
+		}
+		if err := skipJSONValue(j); err != nil {
+			return false
+		}
+	}
+	return false
+}
+
+func newOverland(r io.Reader) (PointReader, error) {
+	j := json.NewDecoder(r)
+	if err := readTokens(j, json.Delim('{'), "locations", json.Delim('[')); err != nil {
+		return nil, ErrFormat
+	}
+	return &overland{j: j}, nil
+}
+
+type overland struct {
+	j *json.Decoder
+
+	md Metadata
+}
+
+func (o *overland) Metadata() Metadata { return o.md }
+func (o *overland) inputPos() inputPos { return jsonInputPos(o.j) }
+
+func (o *overland) ReadPoint() (Point, error) {
+	if !o.j.More() {
+		return Point{}, io.EOF
+	}
+
+	var f struct {
+		Geometry struct {
+			Coordinates []float64 `json:"coordinates"`
+		} `json:"geometry"`
+		Properties overlandProps `json:"properties"`
+	}
+	if err := decodeJSONValue(o.j, &f); err != nil {
+		return Point{}, err
+	}
+
+	pos, p := f.Geometry.Coordinates, &f.Properties
+	if len(pos) < 2 {
+		return Point{}, decodeError("invalid overland position %v", pos)
+	}
+
+	ts, err := parseOverlandTime(p.Timestamp)
+	if err != nil {
+		return Point{}, err
+	}
+
+	if o.md.Device == "" {
+		o.md.Device = p.DeviceID
+	}
+
+	pt := Pt(ts, pos[1], pos[0])
+	if p.Acc != nil && *p.Acc >= 0 {
+		pt.Acc = *p.Acc
+	}
+	if p.Alt != nil {
+		pt.Ele.Valid = true
+		pt.Ele.Float64 = *p.Alt
+		if p.VAcc != nil && *p.VAcc >= 0 {
+			pt.Ele.Acc = *p.VAcc
+		}
+	}
+	if p.Speed != nil && *p.Speed >= 0 {
+		pt.Speed = NullFloat64{Valid: true, Float64: *p.Speed}
+	}
+	if p.Course != nil && *p.Course >= 0 {
+		pt.Course = NullFloat64{Valid: true, Float64: *p.Course}
+	}
+	for _, m := range p.Motion {
+		pt.Activity = append(pt.Activity, Activity{Type: m})
+	}
+	if p.Battery != nil && *p.Battery >= 0 {
+		pt.Sensors = Sensors{Battery: *p.Battery * 100}
+	}
+	return pt, nil
+}
+
+type overlandProps struct {
+	Timestamp string `json:"timestamp"`
+
+	Alt    *float64 `json:"altitude"`
+	Speed  *float64 `json:"speed"`
+	Course *float64 `json:"course"`
+	Acc    *float64 `json:"horizontal_accuracy"`
+	VAcc   *float64 `json:"vertical_accuracy"`
+
+	Motion []string `json:"motion"`
+
+	Battery  *float64 `json:"battery_level"`
+	DeviceID string   `json:"device_id"`
+}
+
+// parseOverlandTime parses an ISO 8601 timestamp
+// having a time zone offset with or without colon.
+func parseOverlandTime(s string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05Z0700"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, decodeError("invalid timestamp %q", s)
+}
@@ -0,0 +1,72 @@
+package trackio_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tajtiattila/track/trackio"
+)
+
+var sampleOverland = `{"locations": [ {
+    "type": "Feature",
+    "geometry": { "type": "Point", "coordinates": [ -122.030581, 37.3318 ] },
+    "properties": {
+      "timestamp": "2017-01-01T10:00:00-0700",
+      "altitude": 80,
+      "speed": 4,
+      "course": -1,
+      "horizontal_accuracy": 30,
+      "vertical_accuracy": -1,
+      "motion": [ "driving", "stationary" ],
+      "battery_level": 0.89,
+      "device_id": "phone"
+    }
+  }, {
+    "type": "Feature",
+    "geometry": { "type": "Point", "coordinates": [ -122.03, 37.332 ] },
+    "properties": { "timestamp": "2017-01-01T17:00:05Z", "horizontal_accuracy": 10 }
+  } ]
+}`
+
+func TestOverland(t *testing.T) {
+	if f, ok := trackio.DetectFormat([]byte(sampleOverland)); !ok || f != "overland" {
+		t.Fatalf("detected format %q", f)
+	}
+
+	d := trackio.NewDecoder(strings.NewReader(sampleOverland))
+	trk, err := d.Track()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(trk) != 2 {
+		t.Fatalf("track length mismatch: want 2 got %d", len(trk))
+	}
+
+	p := trk[0]
+	pointEqual(t, p, trackio.Pt(time.Date(2017, 1, 1, 17, 0, 0, 0, time.UTC), 37.3318, -122.030581))
+	if p.Acc != 30 || !p.Ele.Valid || p.Ele.Float64 != 80 || p.Ele.Acc != trackio.NoAccuracy {
+		t.Errorf("got accuracy %v elevation %+v", p.Acc, p.Ele)
+	}
+	if !p.Speed.Valid || p.Speed.Float64 != 4 || p.Course.Valid {
+		t.Errorf("got speed %v course %v, want 4 and invalid", p.Speed, p.Course)
+	}
+	if len(p.Activity) != 2 || p.Activity[0].Type != "driving" {
+		t.Errorf("got activity %v", p.Activity)
+	}
+	if b := p.Sensors[trackio.Battery]; b != 89 {
+		t.Errorf("got battery %v, want 89", b)
+	}
+
+	if dev := d.Metadata().Device; dev != "phone" {
+		t.Errorf("got device %q, want %q", dev, "phone")
+	}
+}
+
+func TestOverlandInvalid(t *testing.T) {
+	bad := strings.Replace(sampleOverland, `{"locations": [ {`,
+		`{"locations": [ { "type": "Feature", "geometry": 42 }, {`, 1)
+	truncated := sampleOverland[:strings.Index(sampleOverland, "-122.03,")]
+	testInvalidJSON(t, "overland", sampleOverland, bad, 1, truncated)
+}
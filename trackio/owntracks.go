@@ -0,0 +1,157 @@
+package trackio
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+)
+
+func init() {
+	RegisterFormat("owntracks", isOwnTracks, newOwnTracks)
+}
+
+/* OwnTracks Recorder .rec format:
+
+2018-01-01T10:00:00Z	*                 	{"_type":"location","tid":"ph","tst":1514800800,"lat":47.5,"lon":19.05,"acc":12,"alt":120,"vac":3,"vel":5,"cog":270,"batt":87}
+2018-01-01T10:00:30Z	*                 	{"_type":"location","tid":"ph","tst":1514800830,"lat":47.6,"lon":19.06,"acc":12}
+
+Each line holds the time the message was received, the topic
+(or "*" with padding) and the JSON message, separated by tabs.
+Lines holding only the JSON message are accepted as well.
+
+Messages having "_type":"location" are decoded as track points,
+other messages are ignored. The "tst" timestamp is in
+seconds since the Unix epoch, "vel" is the speed in km/h
+and "batt" is the battery level in percent.
+The tracker ID "tid" is decoded as Metadata.Device.
+
+see https://owntracks.org/booklet/tech/json/
+
+*/
+
+func isOwnTracks(p []byte) bool {
+	p = bytes.TrimLeft(p, " \t\r\n")
+	if i := bytes.IndexByte(p, '\n'); i >= 0 {
+		p = p[:i]
+	}
+
+	f := bytes.Split(p, []byte("\t"))
+	if len(f) == 3 {
+		if _, err := time.Parse(time.RFC3339, string(bytes.TrimSpace(f[0]))); err != nil {
+			return false
+		}
+		p = f[2]
+	}
+
+	t, ok := jsonObjectString(p, "_type")
+	return ok && t != ""
+}
+
+func newOwnTracks(r io.Reader) (PointReader, error) {
+	s := newLineScanner(r)
+	s.Buffer(nil, 1<<20)
+	return &ownTracks{s: s}, nil
+}
+
+type ownTracks struct {
+	s *lineScanner
+
+	md Metadata
+}
+
+func (o *ownTracks) Metadata() Metadata { return o.md }
+func (o *ownTracks) inputPos() inputPos { return o.s.inputPos() }
+
+func (o *ownTracks) ReadPoint() (Point, error) {
+	for o.s.Scan() {
+		line := strings.TrimSpace(o.s.Text())
+		if line == "" {
+			continue
+		}
+
+		var recv string
+		if f := strings.Split(line, "\t"); len(f) == 3 {
+			recv, line = strings.TrimSpace(f[0]), f[2]
+		}
+
+		var m ownTracksMsg
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			return Point{}, newDecodeError(err)
+		}
+		if m.Type != "location" {
+			continue
+		}
+
+		return o.point(&m, recv)
+	}
+
+	if err := o.s.Err(); err != nil {
+		return Point{}, err
+	}
+	return Point{}, io.EOF
+}
+
+func (o *ownTracks) point(m *ownTracksMsg, recv string) (Point, error) {
+	if m.Lat == nil || m.Long == nil {
+		return Point{}, decodeError("owntracks location without position")
+	}
+
+	var ts time.Time
+	switch {
+	case m.Timestamp != nil:
+		ts = time.Unix(*m.Timestamp, 0).UTC()
+	case recv != "":
+		var err error
+		if ts, err = time.Parse(time.RFC3339, recv); err != nil {
+			return Point{}, decodeError("invalid timestamp %q", recv)
+		}
+		ts = ts.UTC()
+	default:
+		return Point{}, decodeError("owntracks location without timestamp")
+	}
+
+	if o.md.Device == "" {
+		o.md.Device = m.TrackerID
+	}
+
+	pt := Pt(ts, *m.Lat, *m.Long)
+	if m.Acc != nil && *m.Acc > 0 {
+		pt.Acc = *m.Acc
+	}
+	if m.Alt != nil {
+		pt.Ele.Valid = true
+		pt.Ele.Float64 = *m.Alt
+		if m.VAcc != nil && *m.VAcc > 0 {
+			pt.Ele.Acc = *m.VAcc
+		}
+	}
+	if m.Vel != nil && *m.Vel >= 0 {
+		// km/h
+		pt.Speed = NullFloat64{Valid: true, Float64: *m.Vel / 3.6}
+	}
+	if m.Cog != nil && *m.Cog >= 0 {
+		pt.Course = NullFloat64{Valid: true, Float64: *m.Cog}
+	}
+	if m.Batt != nil {
+		pt.Sensors = Sensors{Battery: *m.Batt}
+	}
+	return pt, nil
+}
+
+type ownTracksMsg struct {
+	Type string `json:"_type"`
+
+	TrackerID string `json:"tid"`
+	Timestamp *int64 `json:"tst"`
+
+	Lat  *float64 `json:"lat"`
+	Long *float64 `json:"lon"`
+	Acc  *float64 `json:"acc"`
+	Alt  *float64 `json:"alt"`
+	VAcc *float64 `json:"vac"`
+	Vel  *float64 `json:"vel"`
+	Cog  *float64 `json:"cog"`
+	Batt *float64 `json:"batt"`
+}
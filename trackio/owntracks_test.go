@@ -0,0 +1,58 @@
+package trackio_test
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tajtiattila/track/trackio"
+)
+
+var sampleOwnTracks = `2018-01-01T10:00:00Z	*                 	{"_type":"location","tid":"ph","tst":1514800800,"lat":47.5,"lon":19.05,"acc":12,"alt":120,"vac":3,"vel":18,"cog":270,"batt":87}
+2018-01-01T10:00:10Z	*                 	{"_type":"lwt","tst":1514800810}
+2018-01-01T10:00:30Z	*                 	{"_type":"location","tid":"ph","lat":47.6,"lon":19.06,"acc":15}
+{"_type":"location","tst":1514800860,"lat":47.7,"lon":19.07}
+`
+
+func TestOwnTracks(t *testing.T) {
+	if f, ok := trackio.DetectFormat([]byte(sampleOwnTracks)); !ok || f != "owntracks" {
+		t.Fatalf("detected format %q", f)
+	}
+
+	d := trackio.NewDecoder(strings.NewReader(sampleOwnTracks))
+	d.Accuracy = trackio.NoAccuracy
+	trk, err := d.Track()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(trk) != 3 {
+		t.Fatalf("track length mismatch: want 3 got %d", len(trk))
+	}
+
+	p := trk[0]
+	pointEqual(t, p, trackio.Pt(time.Date(2018, 1, 1, 10, 0, 0, 0, time.UTC), 47.5, 19.05))
+	if p.Acc != 12 || !p.Ele.Valid || p.Ele.Float64 != 120 || p.Ele.Acc != 3 {
+		t.Errorf("got accuracy %v elevation %+v", p.Acc, p.Ele)
+	}
+	if !p.Speed.Valid || math.Abs(p.Speed.Float64-5) > 1e-9 || p.Course.Float64 != 270 {
+		t.Errorf("got speed %v course %v, want 5 m/s and 270", p.Speed, p.Course)
+	}
+	if b := p.Sensors[trackio.Battery]; b != 87 {
+		t.Errorf("got battery %v, want 87", b)
+	}
+
+	// time from the receive time stamp
+	want := time.Date(2018, 1, 1, 10, 0, 30, 0, time.UTC)
+	if !trk[1].Time.Equal(want) {
+		t.Errorf("got time %v, want %v", trk[1].Time, want)
+	}
+	if trk[2].Acc != trackio.NoAccuracy {
+		t.Errorf("got accuracy %v, want none", trk[2].Acc)
+	}
+
+	if dev := d.Metadata().Device; dev != "ph" {
+		t.Errorf("got device %q, want %q", dev, "ph")
+	}
+}
@@ -0,0 +1,172 @@
+package trackio
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Photo is a geotagged photo.
+type Photo struct {
+	// Path is the slash-separated path of the photo
+	// within the file system it was read from.
+	Path string
+
+	Point // location and time of the photo
+}
+
+// PhotoReader is implemented by PointReaders
+// that decode geotagged photos.
+type PhotoReader interface {
+	// Photos returns the photos decoded so far
+	// in chronological order.
+	Photos() []Photo
+}
+
+// Photos returns the photos decoded so far
+// by the underlying PointReader.
+//
+// It returns nil if the source has no photos.
+func (d *Decoder) Photos() []Photo {
+	if pr, ok := d.PointReader.(PhotoReader); ok {
+		return pr.Photos()
+	}
+	return nil
+}
+
+// NewPhotoDecoder returns a new decoder that reads geotagged
+// JPEG and HEIC photos within the directory root of fsys
+// with Accuracy set to DefaultAccuracy.
+//
+// Track points are decoded from the EXIF GPSLatitude, GPSLongitude,
+// GPSAltitude, GPSHPositioningError, GPSDateStamp and GPSTimeStamp
+// tags. DateTimeOriginal is used when the GPS time is missing,
+// in the local time zone unless OffsetTimeOriginal is present.
+//
+// Photos are returned in chronological order, therefore
+// the decoder reads all photos before returning the first point.
+// Photos without location are ignored, and photos with
+// invalid EXIF data yield a *DecodeError.
+// Paths of the photos are available through Decoder.Photos.
+func NewPhotoDecoder(fsys fs.FS, root string) *Decoder {
+	return newDecoder(&photoDir{fsys: fsys, root: root})
+}
+
+type photoDir struct {
+	fsys fs.FS
+	root string
+
+	read bool // directory has been read
+
+	photos []Photo
+	errs   []error // decode errors not yet returned
+	n      int     // number of points returned
+}
+
+func (d *photoDir) Photos() []Photo { return d.photos[:d.n] }
+
+func (d *photoDir) ReadPoint() (Point, error) {
+	if !d.read {
+		d.read = true
+		if err := d.readDir(); err != nil {
+			return Point{}, err
+		}
+	}
+
+	if len(d.errs) != 0 {
+		err := d.errs[0]
+		d.errs = d.errs[1:]
+		return Point{}, err
+	}
+
+	if d.n == len(d.photos) {
+		return Point{}, io.EOF
+	}
+	p := d.photos[d.n].Point
+	d.n++
+	return p, nil
+}
+
+func (d *photoDir) readDir() error {
+	err := fs.WalkDir(d.fsys, d.root, func(fn string, e fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if e.IsDir() || !isPhotoName(fn) {
+			return nil
+		}
+
+		pt, ok, err := readPhoto(d.fsys, fn)
+		switch {
+		case err != nil:
+			d.errs = append(d.errs, newDecodeError(fmt.Errorf("%s: %v", fn, err)))
+		case ok:
+			d.photos = append(d.photos, Photo{Path: fn, Point: pt})
+		}
+		return nil
+	})
+
+	sort.SliceStable(d.photos, func(i, j int) bool {
+		return d.photos[i].Time.Before(d.photos[j].Time)
+	})
+	return err
+}
+
+func isPhotoName(fn string) bool {
+	switch strings.ToLower(path.Ext(fn)) {
+	case ".jpg", ".jpeg", ".heic", ".heif":
+		return true
+	}
+	return false
+}
+
+// readPhoto returns the track point of the photo fn.
+// It returns ok == false if the photo has no location.
+func readPhoto(fsys fs.FS, fn string) (pt Point, ok bool, err error) {
+	var exif []byte
+	switch strings.ToLower(path.Ext(fn)) {
+	case ".heic", ".heif":
+		var p []byte
+		if p, err = fs.ReadFile(fsys, fn); err != nil {
+			return Point{}, false, err
+		}
+		exif, err = heifExif(p)
+	default:
+		var f fs.File
+		if f, err = fsys.Open(fn); err != nil {
+			return Point{}, false, err
+		}
+		exif, err = readJPEGExif(f)
+		f.Close()
+	}
+	if err == errNoExif {
+		return Point{}, false, nil
+	}
+	if err != nil {
+		return Point{}, false, err
+	}
+
+	x, err := decodeExif(exif)
+	if err == errNoExif {
+		return Point{}, false, nil
+	}
+	if err != nil || !x.hasPos {
+		return Point{}, false, err
+	}
+
+	t, err := x.time()
+	if err != nil {
+		return Point{}, false, err
+	}
+
+	pt = Pt(t, x.lat, x.long)
+	pt.Acc = x.acc
+	if x.hasEle {
+		pt.Ele.Valid = true
+		pt.Ele.Float64 = x.ele
+	}
+	return pt, true, nil
+}
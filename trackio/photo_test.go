@@ -0,0 +1,226 @@
+package trackio_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/tajtiattila/track/trackio"
+)
+
+// exifEntry is an IFD entry for testExif.
+type exifEntry struct {
+	tag, typ uint16
+	value    interface{} // string, []uint32 rationals (num, den pairs) or uint32
+}
+
+// testExif returns big endian EXIF data with an Exif IFD and a GPS IFD.
+func testExif(exif, gps []exifEntry) []byte {
+	bo := binary.BigEndian
+
+	const ifd0Off = 8
+	const ifdSize = 2 + 2*12 + 4 // IFD0 has two entries
+	exifOff := ifd0Off + ifdSize
+	gpsOff := exifOff + 2 + len(exif)*12 + 4
+	dataOff := gpsOff + 2 + len(gps)*12 + 4
+
+	var ifds, data bytes.Buffer
+	writeIFD := func(entries []exifEntry) {
+		binary.Write(&ifds, bo, uint16(len(entries)))
+		for _, e := range entries {
+			var v []byte
+			var count uint32
+			switch x := e.value.(type) {
+			case string:
+				v, count = append([]byte(x), 0), uint32(len(x)+1)
+			case []uint32:
+				for _, n := range x {
+					v = bo.AppendUint32(v, n)
+				}
+				count = uint32(len(x) / 2)
+			case uint32:
+				v, count = bo.AppendUint32(nil, x), 1
+			}
+			binary.Write(&ifds, bo, e.tag)
+			binary.Write(&ifds, bo, e.typ)
+			binary.Write(&ifds, bo, count)
+			if len(v) <= 4 {
+				ifds.Write(append(v, make([]byte, 4-len(v))...))
+			} else {
+				binary.Write(&ifds, bo, uint32(dataOff+data.Len()))
+				data.Write(v)
+			}
+		}
+		binary.Write(&ifds, bo, uint32(0))
+	}
+
+	writeIFD([]exifEntry{
+		{0x8769, 4, uint32(exifOff)},
+		{0x8825, 4, uint32(gpsOff)},
+	})
+	writeIFD(exif)
+	writeIFD(gps)
+
+	buf := bytes.NewBufferString("MM\x00*")
+	binary.Write(buf, bo, uint32(ifd0Off))
+	buf.Write(ifds.Bytes())
+	buf.Write(data.Bytes())
+	return buf.Bytes()
+}
+
+func testJPEG(exif []byte) []byte {
+	buf := bytes.NewBuffer([]byte{0xff, 0xd8})
+	// JFIF APP0
+	buf.Write([]byte{0xff, 0xe0, 0, 4, 0, 0})
+	buf.Write([]byte{0xff, 0xe1})
+	binary.Write(buf, binary.BigEndian, uint16(len(exif)+8))
+	buf.WriteString("Exif\x00\x00")
+	buf.Write(exif)
+	buf.Write([]byte{0xff, 0xda, 0, 2, 0xff, 0xd9})
+	return buf.Bytes()
+}
+
+func testHEIC(exif []byte) []byte {
+	box := func(typ string, body ...[]byte) []byte {
+		b := bytes.Join(body, nil)
+		v := binary.BigEndian.AppendUint32(nil, uint32(len(b)+8))
+		return append(append(v, typ...), b...)
+	}
+
+	ftyp := box("ftyp", []byte("heic\x00\x00\x00\x00mif1heic"))
+	infe := box("infe", []byte{2, 0, 0, 0, 0, 7, 0, 0}, []byte("Exif"))
+	iinf := box("iinf", []byte{0, 0, 0, 0, 0, 1}, infe)
+
+	item := append([]byte{0, 0, 0, 6}, "Exif\x00\x00"...)
+	item = append(item, exif...)
+
+	ilocBody := func(off uint32) []byte {
+		v := []byte{0, 0, 0, 0, 0x44, 0, 0, 1, 0, 7, 0, 0, 0, 1}
+		v = binary.BigEndian.AppendUint32(v, off)
+		return binary.BigEndian.AppendUint32(v, uint32(len(item)))
+	}
+	meta := func(off uint32) []byte {
+		return box("meta", []byte{0, 0, 0, 0}, iinf, box("iloc", ilocBody(off)))
+	}
+
+	head := len(ftyp) + len(meta(0)) + 8
+	return bytes.Join([][]byte{ftyp, meta(uint32(head)), box("mdat", item)}, nil)
+}
+
+// testHEICLocation returns a HEIC file with an Exif item
+// at offset off having length n, using 8 byte iloc fields.
+func testHEICLocation(off, n uint64) []byte {
+	box := func(typ string, body ...[]byte) []byte {
+		b := bytes.Join(body, nil)
+		v := binary.BigEndian.AppendUint32(nil, uint32(len(b)+8))
+		return append(append(v, typ...), b...)
+	}
+
+	ftyp := box("ftyp", []byte("heic\x00\x00\x00\x00mif1heic"))
+	infe := box("infe", []byte{2, 0, 0, 0, 0, 7, 0, 0}, []byte("Exif"))
+	iinf := box("iinf", []byte{0, 0, 0, 0, 0, 1}, infe)
+
+	iloc := []byte{0, 0, 0, 0, 0x88, 0, 0, 1, 0, 7, 0, 0, 0, 1}
+	iloc = binary.BigEndian.AppendUint64(iloc, off)
+	iloc = binary.BigEndian.AppendUint64(iloc, n)
+
+	meta := box("meta", []byte{0, 0, 0, 0}, iinf, box("iloc", iloc))
+	return bytes.Join([][]byte{ftyp, meta}, nil)
+}
+
+func TestPhotoDecoderInvalidLocation(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.heic": {Data: testHEICLocation(0xFFFFFFFFFFFFFFFF, 5)},
+		"b.heic": {Data: testHEICLocation(4, 0xFFFFFFFFFFFFFFFE)},
+		"c.jpg": {Data: testJPEG(testExif(
+			[]exifEntry{{0x9003, 2, "2018:01:01 11:00:00"}},
+			[]exifEntry{
+				{0x01, 2, "N"},
+				{0x02, 5, []uint32{47, 1, 30, 1, 0, 1}},
+				{0x03, 2, "E"},
+				{0x04, 5, []uint32{19, 1, 3, 1, 0, 1}},
+			}))},
+	}
+
+	d := trackio.NewPhotoDecoder(fsys, ".")
+	d.Accuracy = trackio.NoAccuracy
+	var errs []*trackio.DecodeError
+	d.HandleDecodeError = func(e *trackio.DecodeError) error {
+		errs = append(errs, e)
+		return nil
+	}
+	trk, err := d.Track()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trk) != 1 {
+		t.Fatalf("got %d points, want 1", len(trk))
+	}
+	if len(errs) != 2 {
+		t.Fatalf("got %d decode errors, want 2", len(errs))
+	}
+}
+
+func TestPhotoDecoder(t *testing.T) {
+	const rational, ascii = 5, 2
+	fsys := fstest.MapFS{
+		"day/b.jpg": {Data: testJPEG(testExif(
+			[]exifEntry{{0x9003, ascii, "2018:01:01 11:00:00"}},
+			[]exifEntry{
+				{0x01, ascii, "N"},
+				{0x02, rational, []uint32{47, 1, 30, 1, 0, 1}},
+				{0x03, ascii, "E"},
+				{0x04, rational, []uint32{19, 1, 3, 1, 0, 1}},
+				{0x06, rational, []uint32{1205, 10}},
+				{0x07, rational, []uint32{10, 1, 5, 1, 30, 1}},
+				{0x1d, ascii, "2018:01:01"},
+				{0x1f, rational, []uint32{8, 1}},
+			}))},
+		"day/a.HEIC": {Data: testHEIC(testExif(
+			[]exifEntry{
+				{0x9003, ascii, "2018:01:01 10:00:00"},
+				{0x9011, ascii, "+01:00"},
+			},
+			[]exifEntry{
+				{0x01, ascii, "S"},
+				{0x02, rational, []uint32{33, 1, 51, 1, 0, 1}},
+				{0x03, ascii, "W"},
+				{0x04, rational, []uint32{151, 1, 12, 1, 0, 1}},
+			}))},
+		"day/nogps.jpg": {Data: testJPEG(testExif(
+			[]exifEntry{{0x9003, ascii, "2018:01:01 12:00:00"}}, nil))},
+		"day/notes.txt": {Data: []byte("not a photo")},
+	}
+
+	d := trackio.NewPhotoDecoder(fsys, ".")
+	d.Accuracy = trackio.NoAccuracy
+	trk, err := d.Track()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(trk) != 2 {
+		t.Fatalf("track length mismatch: want 2 got %d", len(trk))
+	}
+
+	pointEqual(t, trk[0], trackio.Pt(
+		time.Date(2018, 1, 1, 9, 0, 0, 0, time.UTC),
+		-(33+51.0/60),
+		-(151+12.0/60),
+	))
+	pointEqual(t, trk[1], trackio.Pt(
+		time.Date(2018, 1, 1, 10, 5, 30, 0, time.UTC),
+		47.5,
+		19.05,
+	))
+	if p := trk[1]; p.Acc != 8 || !p.Ele.Valid || p.Ele.Float64 != 120.5 {
+		t.Errorf("got accuracy %v elevation %+v, want 8 and 120.5", p.Acc, p.Ele)
+	}
+
+	photos := d.Photos()
+	if len(photos) != 2 || photos[0].Path != "day/a.HEIC" || photos[1].Path != "day/b.jpg" {
+		t.Errorf("got photos %+v", photos)
+	}
+}
@@ -0,0 +1,59 @@
+package trackio
+
+import "time"
+
+// Visit is a stay at a place, as recognized by
+// location history services such as Google Timeline.
+type Visit struct {
+	Start, End time.Time // time of arrival and departure
+
+	Lat  float64 // degrees of latitude
+	Long float64 // degrees of longitude
+
+	Name    string // place name, if known
+	Address string // place address, if known
+	PlaceID string // service specific place id, if known
+}
+
+// ActivitySegment is a movement between two places.
+type ActivitySegment struct {
+	Start, End time.Time
+
+	Type     string  // activity type, such as "WALKING" or "IN_VEHICLE"
+	Distance float64 // distance travelled (meters), or zero if unknown
+
+	StartLat, StartLong float64 // start position
+	EndLat, EndLong     float64 // end position
+}
+
+// PlaceReader is implemented by PointReaders that
+// decode place visits and activity segments.
+type PlaceReader interface {
+	// Visits returns the place visits decoded so far.
+	Visits() []Visit
+
+	// ActivitySegments returns the activity segments decoded so far.
+	ActivitySegments() []ActivitySegment
+}
+
+// Visits returns the place visits decoded so far
+// by the underlying PointReader.
+//
+// It returns nil if the format has no place visits.
+func (d *Decoder) Visits() []Visit {
+	if pr, ok := d.PointReader.(PlaceReader); ok {
+		return pr.Visits()
+	}
+	return nil
+}
+
+// ActivitySegments returns the activity segments decoded so far
+// by the underlying PointReader.
+//
+// It returns nil if the format has no activity segments.
+func (d *Decoder) ActivitySegments() []ActivitySegment {
+	if pr, ok := d.PointReader.(PlaceReader); ok {
+		return pr.ActivitySegments()
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package trackio
+
+import (
+	"bufio"
+	"io"
+)
+
+// inputPos is a position within the input of a PointReader.
+type inputPos struct {
+	offset       int64 // byte offset, or -1 if unknown
+	line, column int   // 1-based line and column, or zero if unknown
+}
+
+// inputPositioner is implemented by PointReaders that know
+// the input position of the record decoded last.
+//
+// Decoder uses it to set the position of DecodeErrors.
+type inputPositioner interface {
+	inputPos() inputPos
+}
+
+// lineScanner is a bufio.Scanner reading lines
+// that keeps track of the position of the current line.
+type lineScanner struct {
+	*bufio.Scanner
+
+	line   int   // 1-based line number of the current line
+	offset int64 // byte offset of the current line
+	next   int64 // byte offset of the next line
+}
+
+func newLineScanner(r io.Reader) *lineScanner {
+	s := &lineScanner{Scanner: bufio.NewScanner(r)}
+	s.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		n, tok, err := bufio.ScanLines(data, atEOF)
+		if tok != nil {
+			s.line++
+			s.offset = s.next
+			s.next += int64(n)
+		}
+		return n, tok, err
+	})
+	return s
+}
+
+func (s *lineScanner) inputPos() inputPos {
+	if s.line == 0 {
+		return inputPos{offset: -1}
+	}
+	return inputPos{offset: s.offset, line: s.line, column: 1}
+}
@@ -0,0 +1,142 @@
+package trackio
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// Progress describes the progress of decoding.
+type Progress struct {
+	// Bytes is the number of bytes read from the input stream.
+	// For compressed input it is the number of compressed bytes.
+	Bytes int64
+
+	Accepted int // track points accepted
+	Rejected int // track points rejected because of their accuracy
+	Errors   int // decode errors ignored by HandleDecodeError
+}
+
+// progressInterval is the minimum interval
+// between calls to Decoder.ReportProgress.
+const progressInterval = 100 * time.Millisecond
+
+// Progress returns the progress of decoding so far.
+//
+// Points accepted before the first point with
+// a valid accuracy value are counted as rejected
+// once such a point has been decoded.
+func (d *Decoder) Progress() Progress {
+	p := d.progress
+	if d.input != nil {
+		p.Bytes = d.input.n()
+	}
+	return p
+}
+
+// Points reads track points from the underlying PointReader,
+// and calls fn with each point accepted by d, until the end of input.
+//
+// Reset is true when the client should throw away
+// the point(s) passed to fn so far, as with Decoder.Point.
+//
+// Points honors the cancellation of ctx between points,
+// and returns ctx.Err() if ctx is done.
+// If fn returns an error, Points stops and returns that error.
+//
+// ReportProgress, if not nil, is called periodically during decoding,
+// and once more before Points returns.
+func (d *Decoder) Points(ctx context.Context, fn func(pt Point, reset bool) error) error {
+	var last time.Time
+	report := func(force bool) {
+		if d.ReportProgress == nil {
+			return
+		}
+		if now := time.Now(); force || now.Sub(last) >= progressInterval {
+			last = now
+			d.ReportProgress(d.Progress())
+		}
+	}
+	defer report(true)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		pt, reset, err := d.Point()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		if err := fn(pt, reset); err != nil {
+			return err
+		}
+
+		report(false)
+	}
+}
+
+// TrackContext is like Track, but it uses Points
+// to honor the cancellation of ctx and to report progress.
+func (d *Decoder) TrackContext(ctx context.Context) (Track, error) {
+	var trk Track
+	err := d.Points(ctx, func(p Point, reset bool) error {
+		if reset {
+			trk = trk[:0]
+		}
+		trk = append(trk, p)
+		return nil
+	})
+	trk.Sort()
+	return trk, err
+}
+
+// countReader is an io.Reader counting the bytes read.
+type countReader interface {
+	io.Reader
+	n() int64
+}
+
+// newCountReader returns a countReader reading from r.
+// The countReader implements io.ReaderAt and io.Seeker
+// if r implements both.
+func newCountReader(r io.Reader) countReader {
+	c := &countingReader{r: r}
+	if rs, ok := r.(readSeekerAt); ok {
+		return &countingReaderAt{c, rs}
+	}
+	return c
+}
+
+type countingReader struct {
+	r     io.Reader
+	count int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	atomic.AddInt64(&c.count, int64(n))
+	return n, err
+}
+
+func (c *countingReader) n() int64 { return atomic.LoadInt64(&c.count) }
+
+type countingReaderAt struct {
+	*countingReader
+	rs readSeekerAt
+}
+
+func (c *countingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := c.rs.ReadAt(p, off)
+	atomic.AddInt64(&c.count, int64(n))
+	return n, err
+}
+
+func (c *countingReaderAt) Seek(offset int64, whence int) (int64, error) {
+	return c.rs.Seek(offset, whence)
+}
@@ -0,0 +1,62 @@
+package trackio_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/tajtiattila/track/trackio"
+)
+
+func TestDecoderPoints(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString("time,lat,lon,acc\n")
+	for i := 0; i < 100; i++ {
+		acc := 5
+		if i%10 == 0 {
+			acc = 500
+		}
+		fmt.Fprintf(&sb, "%d,47.5,19.05,%d\n", 1514800800+i, acc)
+	}
+	src := sb.String()
+
+	d := trackio.NewDecoder(strings.NewReader(src))
+	d.Accuracy = 100
+	var last trackio.Progress
+	d.ReportProgress = func(p trackio.Progress) { last = p }
+
+	n := 0
+	err := d.Points(context.Background(), func(pt trackio.Point, reset bool) error {
+		n++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := trackio.Progress{Bytes: int64(len(src)), Accepted: 90, Rejected: 10}
+	if last != want || d.Progress() != want {
+		t.Fatalf("got progress %+v, want %+v", last, want)
+	}
+	if n != 90 {
+		t.Fatalf("got %d points, want 90", n)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d = trackio.NewDecoder(strings.NewReader(src))
+	n = 0
+	err = d.Points(ctx, func(pt trackio.Point, reset bool) error {
+		n++
+		if n == 3 {
+			cancel()
+		}
+		return nil
+	})
+	if err != context.Canceled {
+		t.Fatalf("got error %v, want context.Canceled", err)
+	}
+	if n != 3 {
+		t.Fatalf("got %d points after cancel, want 3", n)
+	}
+}
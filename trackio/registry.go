@@ -0,0 +1,313 @@
+package trackio
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Registry holds track file formats and encoders.
+//
+// The zero value is an empty registry ready to use.
+// Registries may be used by multiple goroutines simultaneously.
+//
+// Libraries needing a set of formats different from
+// DefaultRegistry may build their own Registry,
+// or start from a copy of DefaultRegistry using Clone.
+type Registry struct {
+	mu       sync.RWMutex
+	formats  []format
+	encoders []encoder
+	dirs     []dirFormat
+}
+
+// DefaultRegistry holds the formats and encoders of this package.
+//
+// It is used by RegisterFormat, RegisterEncoder, DetectFormat,
+// NewDecoder, NewDecoderFormat and NewEncoder.
+var DefaultRegistry = new(Registry)
+
+type format struct {
+	name   string
+	detect DetectFunc
+	newpr  NewPointReader
+}
+
+type encoder struct {
+	name  string
+	newpw NewPointWriter
+}
+
+type dirFormat struct {
+	name string
+	open OpenDirFunc
+}
+
+// OpenDirFunc returns a decoder for the directory dir.
+//
+// It returns ok == false if dir is not in its format.
+type OpenDirFunc func(dir string) (d *Decoder, ok bool, err error)
+
+// Clone returns a copy of r.
+func (r *Registry) Clone() *Registry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return &Registry{
+		formats:  append([]format(nil), r.formats...),
+		encoders: append([]encoder(nil), r.encoders...),
+		dirs:     append([]dirFormat(nil), r.dirs...),
+	}
+}
+
+// RegisterFormat registers a new track file format in r.
+//
+// Formats are detected in the order of registration.
+// If detect is nil, the format is never detected,
+// and may be used only with NewDecoderFormat.
+//
+// It returns an error if a format with name is already registered.
+func (r *Registry) RegisterFormat(name string, detect DetectFunc, newPointReader NewPointReader) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, f := range r.formats {
+		if f.name == name {
+			return fmt.Errorf("trackio: format %q already registered", name)
+		}
+	}
+	r.formats = append(r.formats, format{name, detect, newPointReader})
+	return nil
+}
+
+// RegisterEncoder registers a new track file encoder in r.
+//
+// It returns an error if an encoder with name is already registered.
+func (r *Registry) RegisterEncoder(name string, newPointWriter NewPointWriter) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, e := range r.encoders {
+		if e.name == name {
+			return fmt.Errorf("trackio: encoder %q already registered", name)
+		}
+	}
+	r.encoders = append(r.encoders, encoder{name, newPointWriter})
+	return nil
+}
+
+// RegisterDir registers a new directory format in r.
+//
+// Directory formats are tried in the order of registration by OpenFile.
+//
+// It returns an error if a directory format with name is already registered.
+func (r *Registry) RegisterDir(name string, open OpenDirFunc) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, d := range r.dirs {
+		if d.name == name {
+			return fmt.Errorf("trackio: directory format %q already registered", name)
+		}
+	}
+	r.dirs = append(r.dirs, dirFormat{name, open})
+	return nil
+}
+
+// SetDir is like RegisterDir, but it replaces the directory format
+// with name if it is already registered, keeping its order.
+//
+// It may be used to change how a registered directory format
+// is opened in a clone of DefaultRegistry.
+func (r *Registry) SetDir(name string, open OpenDirFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, d := range r.dirs {
+		if d.name == name {
+			r.dirs[i].open = open
+			return
+		}
+	}
+	r.dirs = append(r.dirs, dirFormat{name, open})
+}
+
+// Formats returns the names of the formats in r
+// in the order of registration.
+func (r *Registry) Formats() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	v := make([]string, len(r.formats))
+	for i, f := range r.formats {
+		v[i] = f.name
+	}
+	return v
+}
+
+// Encoders returns the names of the encoders in r
+// in the order of registration.
+func (r *Registry) Encoders() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	v := make([]string, len(r.encoders))
+	for i, e := range r.encoders {
+		v[i] = e.name
+	}
+	return v
+}
+
+// DetectFormat is like the package level DetectFormat
+// but it uses the formats in r.
+func (r *Registry) DetectFormat(data []byte) (string, bool) {
+	f, ok := r.detectFormat(data)
+	return f.name, ok
+}
+
+func (r *Registry) detectFormat(data []byte) (format, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, f := range r.formats {
+		if f.detect != nil && f.detect(data) {
+			return f, true
+		}
+	}
+	return format{}, false
+}
+
+func (r *Registry) format(name string) (format, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, f := range r.formats {
+		if f.name == name {
+			return f, true
+		}
+	}
+	return format{}, false
+}
+
+// NewDecoder is like the package level NewDecoder
+// but it uses the formats in r.
+func (r *Registry) NewDecoder(rd io.Reader) *Decoder {
+	cr := newCountReader(rd)
+
+	rd, err := uncompress(cr, func(p []byte) bool {
+		_, ok := r.detectFormat(p)
+		return ok
+	})
+	if err != nil {
+		return newErrDecoder(err)
+	}
+
+	buf := new(bytes.Buffer)
+	_, err = io.Copy(buf, io.LimitReader(rd, 64<<10))
+	if err != nil {
+		return newErrDecoder(err)
+	}
+
+	f, ok := r.detectFormat(buf.Bytes())
+	if !ok {
+		return newErrDecoder(ErrFormat)
+	}
+
+	return newFormatDecoder(f, io.MultiReader(buf, rd), cr)
+}
+
+// NewDecoderFormat is like the package level NewDecoderFormat
+// but it uses the formats in r.
+func (r *Registry) NewDecoderFormat(rd io.Reader, name string) *Decoder {
+	f, ok := r.format(name)
+	if !ok {
+		return newErrDecoder(ErrFormat)
+	}
+
+	cr := newCountReader(rd)
+
+	detect := f.detect
+	if detect == nil {
+		detect = func([]byte) bool { return false }
+	}
+	rd, err := uncompress(cr, detect)
+	if err != nil {
+		return newErrDecoder(err)
+	}
+
+	return newFormatDecoder(f, rd, cr)
+}
+
+func newFormatDecoder(f format, rd io.Reader, cr countReader) *Decoder {
+	pr, err := f.newpr(rd)
+	if err != nil {
+		return newErrDecoder(err)
+	}
+
+	d := newDecoder(pr)
+	d.input = cr
+	return d
+}
+
+// NewEncoder is like the package level NewEncoder
+// but it uses the encoders in r.
+func (r *Registry) NewEncoder(w io.Writer, name string) *Encoder {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, e := range r.encoders {
+		if e.name == name {
+			return &Encoder{e.newpw(w)}
+		}
+	}
+	return &Encoder{&errPointWriter{ErrFormat}}
+}
+
+// OpenFile is like the package level OpenFile
+// but it uses the formats in r.
+func (r *Registry) OpenFile(path string) (*Decoder, io.Closer, error) {
+	return r.OpenFileFormat(path, "")
+}
+
+// OpenFileFormat is like OpenFile, but track files are decoded
+// in the format name without detection, as with NewDecoderFormat.
+// If name is empty, the format of track files is detected.
+//
+// Directories are opened as with OpenFile.
+func (r *Registry) OpenFileFormat(path, name string) (*Decoder, io.Closer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+
+	if !fi.IsDir() {
+		if name != "" {
+			return r.NewDecoderFormat(f, name), f, nil
+		}
+		return r.NewDecoder(f), f, nil
+	}
+
+	r.mu.RLock()
+	dirs := r.dirs
+	r.mu.RUnlock()
+
+	for _, df := range dirs {
+		d, ok, err := df.open(path)
+		if err != nil {
+			f.Close()
+			return nil, nil, err
+		}
+		if ok {
+			return d, f, nil
+		}
+	}
+	return NewPhotoDecoder(os.DirFS(path), "."), f, nil
+}
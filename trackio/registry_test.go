@@ -0,0 +1,164 @@
+package trackio_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tajtiattila/track/trackio"
+)
+
+func TestRegistry(t *testing.T) {
+	r := new(trackio.Registry)
+
+	// headerless csv is never detected
+	c := &trackio.CSV{
+		Columns:    []string{trackio.CSVTime, trackio.CSVLat, trackio.CSVLong},
+		TimeLayout: trackio.CSVUnix,
+	}
+	if err := r.RegisterFormat("rawcsv", nil, c.NewPointReader); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RegisterFormat("rawcsv", nil, c.NewPointReader); err == nil {
+		t.Fatal("duplicate format registered")
+	}
+
+	const src = "1514800800,47.5,19.05\n1514800805,47.5001,19.0501\n"
+
+	if _, ok := r.DetectFormat([]byte(src)); ok {
+		t.Fatal("format without detect func detected")
+	}
+	if _, err := r.NewDecoder(strings.NewReader(src)).Track(); err != trackio.ErrFormat {
+		t.Fatalf("got error %v, want ErrFormat", err)
+	}
+
+	d := r.NewDecoderFormat(strings.NewReader(src), "rawcsv")
+	d.Accuracy = trackio.NoAccuracy
+	trk, err := d.Track()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trk) != 2 {
+		t.Fatalf("got %d points, want 2", len(trk))
+	}
+	pointEqual(t, trk[1], trackio.Pt(time.Date(2018, 1, 1, 10, 0, 5, 0, time.UTC), 47.5001, 19.0501))
+
+	_, err = r.NewDecoderFormat(strings.NewReader(src), "csv").Track()
+	if err != trackio.ErrFormat {
+		t.Fatalf("got error %v for unknown format, want ErrFormat", err)
+	}
+
+	// explicit format in the default registry
+	_, err = trackio.NewDecoderFormat(strings.NewReader(src), "gpx").Track()
+	if err == nil {
+		t.Fatal("csv decoded as gpx")
+	}
+
+	if f := r.Formats(); len(f) != 1 || f[0] != "rawcsv" {
+		t.Fatalf("got formats %q", f)
+	}
+}
+
+func TestRegistryOpenFile(t *testing.T) {
+	dir := t.TempDir()
+	fn := filepath.Join(dir, "track.csv")
+	if err := os.WriteFile(fn, []byte("1514800800,47.5,19.05\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	r := new(trackio.Registry)
+	c := &trackio.CSV{
+		Columns:    []string{trackio.CSVTime, trackio.CSVLat, trackio.CSVLong},
+		TimeLayout: trackio.CSVUnix,
+	}
+	if err := r.RegisterFormat("rawcsv", nil, c.NewPointReader); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, format := range []string{"", "rawcsv"} {
+		d, closer, err := r.OpenFileFormat(fn, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d.Accuracy = trackio.NoAccuracy
+		trk, err := d.Track()
+		closer.Close()
+		if format == "" && err != trackio.ErrFormat {
+			t.Errorf("got error %v without format, want ErrFormat", err)
+		}
+		if format != "" && (err != nil || len(trk) != 1) {
+			t.Errorf("got %d points and error %v with format %q", len(trk), err, format)
+		}
+	}
+
+	var opened string
+	dirFunc := func(name string) trackio.OpenDirFunc {
+		return func(string) (*trackio.Decoder, bool, error) {
+			opened = name
+			return nil, false, nil
+		}
+	}
+	if err := r.RegisterDir("dir", dirFunc("first")); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RegisterDir("dir", dirFunc("second")); err == nil {
+		t.Fatal("duplicate directory format registered")
+	}
+	r.SetDir("dir", dirFunc("replaced"))
+
+	_, closer, err := r.OpenFile(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closer.Close()
+	if opened != "replaced" {
+		t.Errorf("got directory format %q, want replaced", opened)
+	}
+}
+
+func TestRegistryHeaderlessCSV(t *testing.T) {
+	cols, err := trackio.ParseCSVColumns("Timestamp, latitude,longitude,,alt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{trackio.CSVTime, trackio.CSVLat, trackio.CSVLong, "", trackio.CSVEle}
+	if !reflect.DeepEqual(cols, want) {
+		t.Fatalf("got columns %q, want %q", cols, want)
+	}
+
+	for _, bad := range []string{"time,lat,speed", "time,lat", ""} {
+		if _, err := trackio.ParseCSVColumns(bad); err == nil {
+			t.Errorf("columns %q accepted", bad)
+		}
+	}
+
+	r := new(trackio.Registry)
+	c := &trackio.CSV{Columns: cols, TimeLayout: trackio.CSVUnixMilli}
+	if err := r.RegisterFormat("csv", nil, c.NewPointReader); err != nil {
+		t.Fatal(err)
+	}
+
+	// compressed input without header row
+	const src = "1514800800000,47.5,19.05,x,120\n1514800805000,47.5001,19.0501,y,121\n"
+	d := r.NewDecoderFormat(bytes.NewReader(gzipData(t, src)), "csv")
+	trk, err := d.Track()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trk) != 2 {
+		t.Fatalf("got %d points, want 2", len(trk))
+	}
+	pointEqual(t, trk[0], trackio.Pt(time.Date(2018, 1, 1, 10, 0, 0, 0, time.UTC), 47.5, 19.05))
+	if p := trk[1]; !p.Ele.Valid || p.Ele.Float64 != 121 {
+		t.Errorf("got elevation %+v, want 121", p.Ele)
+	}
+
+	// the default csv format needs a header row
+	if _, err := trackio.NewDecoderFormat(strings.NewReader(src), "csv").Track(); err == nil {
+		t.Error("headerless csv decoded without columns")
+	}
+}
@@ -0,0 +1,183 @@
+package trackio
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+	"time"
+)
+
+func init() {
+	RegisterFormat("tcx", isXML("TrainingCenterDatabase"), newTCX)
+}
+
+/* TCX Format:
+
+<?xml .. ?>
+<TrainingCenterDatabase xmlns="http://www.garmin.com/xmlschemas/TrainingCenterDatabase/v2">
+	<Activities>
+		<Activity Sport="Biking">
+			<Id>2010-06-26T10:06:11Z</Id>
+			<Creator xsi:type="Device_t"><Name>Forerunner 405</Name></Creator>
+			<Lap StartTime="2010-06-26T10:06:11Z">
+				<Track>
+					<Trackpoint>
+						<Time>2010-06-26T10:06:11Z</Time>
+						<Position>
+							<LatitudeDegrees>47.6445</LatitudeDegrees>
+							<LongitudeDegrees>-122.3269</LongitudeDegrees>
+						</Position>
+						<AltitudeMeters>4.46</AltitudeMeters>
+						<HeartRateBpm><Value>96</Value></HeartRateBpm>
+						<Cadence>78</Cadence>
+					</Trackpoint>
+		...
+
+see https://www8.garmin.com/xmlschemas/TrainingCenterDatabasev2.xsd
+
+*/
+
+func newTCX(r io.Reader) (PointReader, error) {
+	d := xml.NewDecoder(r)
+
+	doc, err := nextStartElement(d)
+	if err != nil {
+		return nil, ErrFormat
+	}
+
+	if doc.Name.Local != "TrainingCenterDatabase" {
+		return nil, ErrFormat
+	}
+
+	t := &tcx{trk: -1, seg: -1}
+	want := wantXMLPath("Activities", "Activity", "Lap", "Track", "Trackpoint")
+	t.td = newXMLTreeDecoder(d, func(p []xml.Name, e xml.Name) xmlTreeOp {
+		switch {
+		case len(p) == 0 && e.Local == "Author":
+			return xmlReturn
+		case len(p) == 2 && (e.Local == "Id" || e.Local == "Notes" || e.Local == "Creator"):
+			return xmlReturn
+		}
+
+		op := want(p, e)
+		if op == xmlEnter {
+			// Activities are tracks, and Tracks are segments
+			switch e.Local {
+			case "Activity":
+				t.trk++
+			case "Track":
+				t.seg++
+			}
+		}
+		return op
+	})
+	return t, nil
+}
+
+type tcx struct {
+	td *xmlTreeDecoder
+
+	trk, seg int // current Activity and Track index
+
+	md Metadata
+}
+
+// Metadata returns the Author as Creator and the Creator
+// of the first Activity as Device. Activity Ids and Notes
+// are returned as track names and descriptions.
+func (t *tcx) Metadata() Metadata { return t.md }
+func (t *tcx) inputPos() inputPos { return t.td.inputPos() }
+
+// ReadPoint returns the next Trackpoint having a Position.
+// Trackpoints without position (eg. recorded indoors) are skipped.
+func (t *tcx) ReadPoint() (Point, error) {
+	for {
+		se, err := t.td.next()
+		if err != nil {
+			return Point{}, err
+		}
+
+		if se.Name.Local != "Trackpoint" {
+			if err := t.decodeInfo(se); err != nil {
+				return Point{}, err
+			}
+			continue
+		}
+
+		var p tcxPt
+		if err := t.td.d.DecodeElement(&p, &se); err != nil {
+			return Point{}, err
+		}
+
+		if p.Pos != nil {
+			pt, err := p.point()
+			pt.Trk, pt.Seg = t.trk, t.seg
+			return pt, err
+		}
+	}
+}
+
+func (t *tcx) decodeInfo(se xml.StartElement) error {
+	switch se.Name.Local {
+	case "Author", "Creator":
+		var v struct {
+			Name string `xml:"Name"`
+		}
+		if err := t.td.d.DecodeElement(&v, &se); err != nil {
+			return err
+		}
+		if se.Name.Local == "Author" {
+			t.md.Creator = v.Name
+		} else if t.md.Device == "" {
+			t.md.Device = v.Name
+		}
+	default:
+		s, err := xmlCharData(t.td.d)
+		if err != nil {
+			return err
+		}
+		if se.Name.Local == "Id" {
+			t.md.track(t.trk).Name = s
+		} else {
+			t.md.track(t.trk).Description = s
+		}
+	}
+	return nil
+}
+
+type tcxPt struct {
+	Time string `xml:"Time"`
+	Pos  *struct {
+		Lat  float64 `xml:"LatitudeDegrees"`
+		Long float64 `xml:"LongitudeDegrees"`
+	} `xml:"Position"`
+	Alt string `xml:"AltitudeMeters"`
+	HR  string `xml:"HeartRateBpm>Value"`
+	Cad string `xml:"Cadence"`
+}
+
+func (p *tcxPt) point() (Point, error) {
+	ts, err := time.Parse(time.RFC3339, p.Time)
+	if err != nil {
+		return Point{}, decodeError("invalid timestamp %q", p.Time)
+	}
+
+	pt := Pt(ts.UTC(), p.Pos.Lat, p.Pos.Long)
+
+	if v, err := strconv.ParseFloat(p.Alt, 64); err == nil {
+		pt.Ele.Valid = true
+		pt.Ele.Float64 = v
+	}
+
+	if v, err := strconv.ParseFloat(p.HR, 64); err == nil {
+		pt.Sensors = Sensors{HeartRate: v}
+	}
+	if v, err := strconv.ParseFloat(p.Cad, 64); err == nil {
+		if pt.Sensors == nil {
+			pt.Sensors = make(Sensors)
+		}
+		pt.Sensors[Cadence] = v
+	}
+
+	return pt, nil
+}
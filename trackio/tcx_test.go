@@ -0,0 +1,93 @@
+package trackio_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/tajtiattila/track/trackio"
+)
+
+var sampleTCX = `<?xml version="1.0" encoding="UTF-8" standalone="no" ?>
+<TrainingCenterDatabase xmlns="http://www.garmin.com/xmlschemas/TrainingCenterDatabase/v2" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+  <Activities>
+    <Activity Sport="Biking">
+      <Id>2010-06-26T10:06:11Z</Id>
+      <Lap StartTime="2010-06-26T10:06:11Z">
+        <TotalTimeSeconds>12</TotalTimeSeconds>
+        <Track>
+          <Trackpoint>
+            <Time>2010-06-26T10:06:11Z</Time>
+            <Position>
+              <LatitudeDegrees>47.644548</LatitudeDegrees>
+              <LongitudeDegrees>-122.326897</LongitudeDegrees>
+            </Position>
+            <AltitudeMeters>4.46</AltitudeMeters>
+            <HeartRateBpm><Value>96</Value></HeartRateBpm>
+            <Cadence>78</Cadence>
+          </Trackpoint>
+          <Trackpoint>
+            <Time>2010-06-26T10:06:15Z</Time>
+            <HeartRateBpm><Value>97</Value></HeartRateBpm>
+          </Trackpoint>
+          <Trackpoint>
+            <Time>2010-06-26T10:06:19Z</Time>
+            <Position>
+              <LatitudeDegrees>47.644612</LatitudeDegrees>
+              <LongitudeDegrees>-122.326701</LongitudeDegrees>
+            </Position>
+            <AltitudeMeters>4.94</AltitudeMeters>
+          </Trackpoint>
+        </Track>
+      </Lap>
+      <Lap StartTime="2010-06-26T10:06:23Z">
+        <Track>
+          <Trackpoint>
+            <Time>2010-06-26T10:06:23Z</Time>
+            <Position>
+              <LatitudeDegrees>47.644705</LatitudeDegrees>
+              <LongitudeDegrees>-122.326523</LongitudeDegrees>
+            </Position>
+            <HeartRateBpm><Value>101</Value></HeartRateBpm>
+          </Trackpoint>
+        </Track>
+      </Lap>
+    </Activity>
+  </Activities>
+</TrainingCenterDatabase>
+`
+
+func TestTCX(t *testing.T) {
+	r := bytes.NewReader([]byte(sampleTCX))
+	trk, err := trackio.NewDecoder(r).Track()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const wantLen = 3
+	if len(trk) != wantLen {
+		t.Fatalf("track length mismatch: want %d got %d", wantLen, len(trk))
+	}
+
+	pointEqual(t, trk[0], trackio.Pt(
+		time.Date(2010, 6, 26, 10, 6, 11, 0, time.UTC),
+		47.644548,
+		-122.326897,
+	))
+
+	if p := trk[0]; !p.Ele.Valid || p.Ele.Float64 != 4.46 {
+		t.Errorf("got elevation %+v, want 4.46", p.Ele)
+	}
+	if hr := trk[0].Sensors[trackio.HeartRate]; hr != 96 {
+		t.Errorf("got heart rate %v, want 96", hr)
+	}
+	if cad := trk[0].Sensors[trackio.Cadence]; cad != 78 {
+		t.Errorf("got cadence %v, want 78", cad)
+	}
+	if s := trk[1].Sensors; s != nil {
+		t.Errorf("got sensors %v, want none", s)
+	}
+	if hr := trk[2].Sensors[trackio.HeartRate]; hr != 101 {
+		t.Errorf("got heart rate %v, want 101", hr)
+	}
+}
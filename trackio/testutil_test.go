@@ -1,6 +1,7 @@
 package trackio_test
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/tajtiattila/track/trackio"
@@ -19,3 +20,47 @@ func pointEqual(t *testing.T, got, want trackio.Point) {
 		t.Fatalf("got latitude %v, want %v", got.Long, want.Long)
 	}
 }
+
+// decodeSkipAll decodes data in format, skipping all decode errors.
+// It returns the decoded track and the number of errors skipped.
+func decodeSkipAll(format, data string) (trackio.Track, int, error) {
+	d := trackio.NewDecoderFormat(strings.NewReader(data), format)
+	d.Accuracy = trackio.NoAccuracy
+	n := 0
+	d.HandleDecodeError = func(e *trackio.DecodeError) error {
+		// guard against an endless loop
+		if n++; n > 10 {
+			return e
+		}
+		return nil
+	}
+	trk, err := d.Track()
+	return trk, n, err
+}
+
+// testInvalidJSON checks that decoding sample in format skips
+// the invalid values of bad, and that truncated input
+// yields an error that is not a *DecodeError.
+func testInvalidJSON(t *testing.T, format, sample, bad string, nbad int, truncated string) {
+	want, _, err := decodeSkipAll(format, sample)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	trk, n, err := decodeSkipAll(format, bad)
+	if err != nil {
+		t.Fatalf("invalid values: %v", err)
+	}
+	if len(trk) != len(want) || n != nbad {
+		t.Errorf("invalid values: got %d points and %d errors, want %d and %d",
+			len(trk), n, len(want), nbad)
+	}
+
+	_, n, err = decodeSkipAll(format, truncated)
+	if _, ok := err.(*trackio.DecodeError); err == nil || ok {
+		t.Errorf("truncated: got error %#v, want fatal error", err)
+	}
+	if n != 0 {
+		t.Errorf("truncated: got %d decode errors, want none", n)
+	}
+}
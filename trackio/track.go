@@ -4,6 +4,7 @@ import (
 	"sort"
 	"time"
 
+	"github.com/tajtiattila/track"
 	"github.com/tajtiattila/track/trackutil"
 )
 
@@ -78,9 +79,60 @@ func (trk Track) At(t time.Time) (lat, long float64) {
 	return trackutil.Lookup(trk, t)
 }
 
+// SegmentStart reports whether the point at index i
+// starts a new track segment.
+func (trk Track) SegmentStart(i int) bool {
+	return i == 0 || trk[i].Seg != trk[i-1].Seg
+}
+
+// HasSegmentTime checks if trk has a position for the given time
+// within one of its segments.
+//
+// Unlike HasTime, it returns false if t falls between two segments.
+func (trk Track) HasSegmentTime(t time.Time) bool {
+	_, _, ok := trackutil.LookupSegment(trk, t)
+	return ok
+}
+
+// AtSegment calculates the interpolated lat and long
+// of trk for the given time.
+//
+// Unlike At, it does not interpolate between segments.
+// It returns ok == false if trk.HasSegmentTime(t) is false.
+func (trk Track) AtSegment(t time.Time) (lat, long float64, ok bool) {
+	return trackutil.LookupSegment(trk, t)
+}
+
+// Segments splits trk into segments
+// of consecutive track points having the same Seg value.
+func (trk Track) Segments() []Track {
+	var v []Track
+	for i := range trk {
+		if trk.SegmentStart(i) {
+			v = append(v, trk[i:i])
+		}
+		v[len(v)-1] = v[len(v)-1][:len(v[len(v)-1])+1]
+	}
+	return v
+}
+
+// Segmented returns trk as a compact track.Segmented,
+// having segment starts where the Seg value of points changes.
+func (trk Track) Segmented() track.Segmented {
+	s := track.Segmented{Track: trk.Compact()}
+	for i := 1; i < len(trk); i++ {
+		if trk.SegmentStart(i) {
+			s.Starts = append(s.Starts, i)
+		}
+	}
+	return s
+}
+
 // Sort sorts trk by track point time stamp.
+//
+// Points having the same time stamp keep their original order.
 func (trk Track) Sort() {
-	sort.Sort(byTime(trk))
+	sort.Stable(byTime(trk))
 }
 
 type byTime []Point
@@ -88,3 +140,48 @@ type byTime []Point
 func (t byTime) Len() int           { return len(t) }
 func (t byTime) Less(i, j int) bool { return t[i].Time.Before(t[j].Time) }
 func (t byTime) Swap(i, j int)      { t[i], t[j] = t[j], t[i] }
+
+// Compact returns p as a compact track.Point.
+//
+// The time, position, horizontal accuracy and elevation of p are kept
+// with the precision of track.Point, other fields are dropped.
+func (p Point) Compact() track.Point {
+	cp := track.Pt(p.Time, p.Lat, p.Long)
+	if p.Acc < NoAccuracy {
+		cp = cp.WithAcc(p.Acc)
+	}
+	if p.Ele.Valid {
+		cp = cp.WithEle(p.Ele.Float64)
+	}
+	return cp
+}
+
+// FromCompact returns the compact point cp as a Point.
+func FromCompact(cp track.Point) Point {
+	p := Pt(cp.Time(), cp.Lat(), cp.Long())
+	if acc, ok := cp.Acc(); ok {
+		p.Acc = acc
+	}
+	if ele, ok := cp.Ele(); ok {
+		p.Ele.Valid, p.Ele.Float64 = true, ele
+	}
+	return p
+}
+
+// Compact returns trk as a compact track.Track.
+func (trk Track) Compact() track.Track {
+	v := make(track.Track, len(trk))
+	for i, p := range trk {
+		v[i] = p.Compact()
+	}
+	return v
+}
+
+// FromCompactTrack returns the compact track ct as a Track.
+func FromCompactTrack(ct track.Track) Track {
+	v := make(Track, len(ct))
+	for i, p := range ct {
+		v[i] = FromCompact(p)
+	}
+	return v
+}
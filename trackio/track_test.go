@@ -0,0 +1,40 @@
+package trackio_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tajtiattila/track/trackio"
+)
+
+func TestCompact(t *testing.T) {
+	src := sampleEncodeTrack()
+
+	got := trackio.FromCompactTrack(src.Compact())
+	if len(got) != len(src) {
+		t.Fatalf("got %d points, want %d", len(got), len(src))
+	}
+
+	for i, want := range src {
+		p := got[i]
+		pointEqual(t, p, want)
+		if p.Acc != want.Acc {
+			t.Errorf("point %d: got accuracy %v, want %v", i, p.Acc, want.Acc)
+		}
+		if p.Ele.Valid != want.Ele.Valid || p.Ele.Float64 != want.Ele.Float64 {
+			t.Errorf("point %d: got elevation %+v, want %+v", i, p.Ele, want.Ele)
+		}
+	}
+}
+
+func TestSegmented(t *testing.T) {
+	src := sampleEncodeTrack()
+	for i := range src {
+		src[i].Seg = i / 4
+	}
+
+	s := src.Segmented()
+	if len(s.Track) != len(src) || !reflect.DeepEqual(s.Starts, []int{4, 8}) {
+		t.Fatalf("got %d points and segment starts %v", len(s.Track), s.Starts)
+	}
+}
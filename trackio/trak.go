@@ -0,0 +1,57 @@
+package trackio
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/tajtiattila/track"
+)
+
+func init() {
+	RegisterFormat("trak", isTrak, newTrak)
+	RegisterEncoder("trak", newTrakWriter)
+}
+
+/* Binary track format:
+
+The compact binary format of track.Track, as written by
+track.Writer and track.Track.MarshalBinary. It stores the time,
+position, horizontal accuracy and elevation of points,
+other Point fields are dropped.
+
+It is useful as a cache for track files that are slow to decode.
+
+*/
+
+func isTrak(p []byte) bool {
+	return bytes.HasPrefix(p, []byte(track.BinaryHeader))
+}
+
+func newTrak(r io.Reader) (PointReader, error) {
+	return &trakReader{r: track.NewReader(r)}, nil
+}
+
+type trakReader struct {
+	r *track.Reader
+}
+
+// ReadPoint returns the next point. Errors are fatal,
+// since the binary format can't be resynchronized.
+func (t *trakReader) ReadPoint() (Point, error) {
+	p, err := t.r.ReadPoint()
+	if err != nil {
+		return Point{}, err
+	}
+	return FromCompact(p), nil
+}
+
+func newTrakWriter(w io.Writer) PointWriter {
+	return &trakWriter{w: track.NewWriter(w)}
+}
+
+type trakWriter struct {
+	w *track.Writer
+}
+
+func (t *trakWriter) WritePoint(p Point) error { return t.w.WritePoint(p.Compact()) }
+func (t *trakWriter) Close() error             { return t.w.Flush() }
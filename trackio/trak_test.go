@@ -0,0 +1,56 @@
+package trackio_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/tajtiattila/track"
+	"github.com/tajtiattila/track/trackio"
+)
+
+func TestTrakInvalid(t *testing.T) {
+	trk := track.Track{
+		track.Pt(time.Date(2018, 1, 1, 10, 0, 0, 0, time.UTC), 47.5, 19.05),
+		track.Pt(time.Date(2018, 1, 1, 10, 0, 5, 0, time.UTC), 47.5001, 19.0501),
+	}
+	data, err := trk.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	badFlags := append([]byte(nil), data...)
+	badFlags[len(track.BinaryHeader)] = 0xff
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"empty", nil, track.ErrBinary},
+		{"truncated", data[:len(data)-1], io.ErrUnexpectedEOF},
+		{"bad flags", badFlags, track.ErrBinary},
+	}
+
+	for _, tt := range tests {
+		d := trackio.NewDecoderFormat(bytes.NewReader(tt.data), "trak")
+		d.Accuracy = trackio.NoAccuracy
+		n := 0
+		d.HandleDecodeError = func(e *trackio.DecodeError) error {
+			// skip all errors, and guard against an endless loop
+			if n++; n > 10 {
+				return e
+			}
+			return nil
+		}
+
+		_, err := d.Track()
+		if err != tt.want {
+			t.Errorf("%s: got error %v, want %v", tt.name, err, tt.want)
+		}
+		if n != 0 {
+			t.Errorf("%s: got %d decode errors, want none", tt.name, n)
+		}
+	}
+}
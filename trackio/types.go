@@ -18,6 +18,26 @@ type Point struct {
 	Acc float64 // estimated horizontal accuracy (meters)
 
 	Ele Elevation // elevation/altitude information
+
+	Speed  NullFloat64 // speed over ground (meters per second)
+	Course NullFloat64 // course over ground (degrees clockwise from true north)
+
+	// Activity holds the activities detected at the point,
+	// in decreasing order of confidence.
+	Activity []Activity
+
+	Sensors Sensors // additional sensor readings, if any
+
+	// Trk and Seg are the indices of the track (such as a GPX trk)
+	// and the track segment (such as a GPX trkseg) of the point
+	// within the input.
+	//
+	// Seg values are unique across tracks,
+	// therefore points with different Trk values
+	// never have the same Seg value.
+	//
+	// For formats without segments both are zero.
+	Trk, Seg int
 }
 
 func Pt(t time.Time, lat, long float64) Point {
@@ -36,3 +56,30 @@ type Elevation struct {
 	Float64 float64 // elevation value in meters above sea level
 	Acc     float64 // estimated vertical accuracy (meters)
 }
+
+// NullFloat64 represents an optional float64 value.
+type NullFloat64 struct {
+	Valid   bool // indicates if Float64 is valid
+	Float64 float64
+}
+
+// Activity is an activity type detected at a track point.
+type Activity struct {
+	Type       string // activity type, such as "WALKING" or "IN_VEHICLE"
+	Confidence int    // confidence of the detection (percent)
+}
+
+// Sensors holds additional sensor readings of a track point
+// keyed by sensor name.
+type Sensors map[string]float64
+
+// Sensor names used by the formats of this package.
+const (
+	HeartRate        = "hr"    // heart rate (beats per minute)
+	Cadence          = "cad"   // cadence (revolutions per minute)
+	Temperature      = "temp"  // air temperature (degrees Celsius)
+	WaterTemperature = "wtemp" // water temperature (degrees Celsius)
+	Depth            = "depth" // depth (meters)
+	Power            = "power" // power (watts)
+	Battery          = "batt"  // battery level of the recording device (percent)
+)
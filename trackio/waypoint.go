@@ -0,0 +1,78 @@
+package trackio
+
+import "time"
+
+// Waypoint is a point of interest, such as a GPX wpt or rtept.
+type Waypoint struct {
+	Time time.Time // time stamp, or the zero time if unknown
+
+	Lat  float64 // degrees of latitude
+	Long float64 // degrees of longitude
+
+	Ele Elevation // elevation/altitude information
+
+	Name        string
+	Description string
+	Symbol      string // symbol name, such as "Flag, Blue"
+}
+
+// Route is an ordered list of waypoints
+// describing a path to be followed.
+type Route struct {
+	Name        string
+	Description string
+
+	Points []Waypoint
+}
+
+// WaypointReader is implemented by PointReaders
+// that decode waypoints and routes.
+type WaypointReader interface {
+	// Waypoints returns the waypoints decoded so far.
+	Waypoints() []Waypoint
+
+	// Routes returns the routes decoded so far.
+	Routes() []Route
+}
+
+// WaypointWriter is implemented by PointWriters
+// that encode waypoints and routes.
+type WaypointWriter interface {
+	// SetWaypoints sets the waypoints and routes to be written.
+	// It must be called before the first call to WritePoint.
+	SetWaypoints(wpts []Waypoint, rtes []Route)
+}
+
+// Waypoints returns the waypoints decoded so far
+// by the underlying PointReader.
+//
+// It returns nil if the format has no waypoints.
+func (d *Decoder) Waypoints() []Waypoint {
+	if wr, ok := d.PointReader.(WaypointReader); ok {
+		return wr.Waypoints()
+	}
+	return nil
+}
+
+// Routes returns the routes decoded so far
+// by the underlying PointReader.
+//
+// It returns nil if the format has no routes.
+func (d *Decoder) Routes() []Route {
+	if wr, ok := d.PointReader.(WaypointReader); ok {
+		return wr.Routes()
+	}
+	return nil
+}
+
+// SetWaypoints sets the waypoints and routes to be written
+// by the underlying PointWriter.
+//
+// It must be called before the first point is written.
+// Waypoints and routes are silently ignored
+// if the format has no support for them.
+func (e *Encoder) SetWaypoints(wpts []Waypoint, rtes []Route) {
+	if ww, ok := e.PointWriter.(WaypointWriter); ok {
+		ww.SetWaypoints(wpts, rtes)
+	}
+}
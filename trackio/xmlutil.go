@@ -74,6 +74,11 @@ func newXMLTreeDecoder(d *xml.Decoder, fn xmlTreeFunc) *xmlTreeDecoder {
 	}
 }
 
+func (x *xmlTreeDecoder) inputPos() inputPos {
+	line, col := x.d.InputPos()
+	return inputPos{offset: x.d.InputOffset(), line: line, column: col}
+}
+
 func (x *xmlTreeDecoder) next() (xml.StartElement, error) {
 	for {
 		tok, err := x.d.Token()
@@ -137,3 +142,11 @@ Loop:
 	}
 	return buf.String(), nil
 }
+
+// xmlEscape returns s escaped to be used
+// as xml character data or attribute value.
+func xmlEscape(s string) string {
+	buf := new(bytes.Buffer)
+	xml.EscapeText(buf, []byte(s))
+	return buf.String()
+}
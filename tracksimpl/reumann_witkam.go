@@ -79,7 +79,23 @@ func (ss ShiftSegment) Run(dst, src track.Track) track.Track {
 			a = src[j-1]
 		} else {
 			lat, long := a3.LatLong()
-			a = track.Pt(bt, lat, long)
+			p := track.Pt(bt, lat, long)
+
+			// interpolate elevation along the strip like the position
+			aele, aok := a.Ele()
+			bele, bok := b.Ele()
+			if aok && bok {
+				dt := float64(bt.Sub(a.Time()))
+				p = p.WithEle(aele + (bele-aele)*dt/ut)
+			}
+
+			// p is within D of the points it replaces
+			if acc := worstAcc(src[i:j]); acc >= 0 {
+				p = p.WithAcc(acc + ss.D)
+			} else {
+				p = p.WithAcc(-1)
+			}
+			a = p
 		}
 
 		dst = append(dst, a)
@@ -38,6 +38,22 @@ func pt3(p track.Point) geomath.Point3 {
 	return geomath.Pt3(p.Lat(), p.Long())
 }
 
+// worstAcc returns the largest horizontal accuracy of the points in trk,
+// or -1 if the accuracy of any of them is unknown.
+func worstAcc(trk track.Track) float64 {
+	var worst float64
+	for _, p := range trk {
+		acc, ok := p.Acc()
+		if !ok {
+			return -1
+		}
+		if acc > worst {
+			worst = acc
+		}
+	}
+	return worst
+}
+
 func dist3sq(a, b geomath.Point3) float64 {
 	d := a.Sub(b)
 	return d.Dot(d)
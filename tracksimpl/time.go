@@ -8,7 +8,8 @@ import (
 
 // RoundTime rounds all time values to Dt in src,
 // and replaces multiple entries with the same time value
-// with a single point having the average location of those points.
+// with a single point having the average location
+// and elevation, and the worst accuracy of those points.
 type RoundTime struct {
 	Dt time.Duration
 }
@@ -43,6 +44,11 @@ type timeFilter struct {
 
 	sumlat, sumlong float64
 	sumn            int
+
+	sumele float64
+	nele   int
+
+	acc float64 // worst accuracy, or -1 if unknown
 }
 
 func (f *timeFilter) start(a track.Point) {
@@ -56,10 +62,16 @@ func (f *timeFilter) next(b track.Point) {
 		if f.sumn == 0 {
 			f.sumlat, f.sumlong = f.a.Lat(), f.a.Long()
 			f.sumn++
+			f.sumele, f.nele = 0, 0
+			f.addEle(f.a)
+			f.acc = 0
+			f.addAcc(f.a)
 		}
 		f.sumlat += b.Lat()
 		f.sumlong += b.Long()
 		f.sumn++
+		f.addEle(b)
+		f.addAcc(b)
 	} else {
 		f.flush()
 		f.a, f.at = b, bt
@@ -69,9 +81,30 @@ func (f *timeFilter) next(b track.Point) {
 
 func (f *timeFilter) flush() {
 	if f.sumn > 0 {
-		m := 1 / float64(f.sumn)
-		f.dst = append(f.dst, track.Pt(f.at, f.sumlat/m, f.sumlong/m))
+		n := float64(f.sumn)
+		p := track.Pt(f.at, f.sumlat/n, f.sumlong/n).WithAcc(f.acc)
+		if f.nele > 0 {
+			p = p.WithEle(f.sumele / float64(f.nele))
+		}
+		f.dst = append(f.dst, p)
 	} else {
 		f.dst = append(f.dst, f.a)
 	}
 }
+
+func (f *timeFilter) addEle(p track.Point) {
+	if ele, ok := p.Ele(); ok {
+		f.sumele += ele
+		f.nele++
+	}
+}
+
+func (f *timeFilter) addAcc(p track.Point) {
+	acc, ok := p.Acc()
+	switch {
+	case !ok:
+		f.acc = -1
+	case f.acc >= 0 && acc > f.acc:
+		f.acc = acc
+	}
+}
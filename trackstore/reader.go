@@ -0,0 +1,58 @@
+package trackstore
+
+import (
+	"io"
+	"time"
+
+	"github.com/tajtiattila/track"
+	"github.com/tajtiattila/track/trackio"
+)
+
+// Reader reads the points of a store within a time range
+// in chronological order, holding only one partition in memory.
+//
+// Reader implements trackio.PointReader,
+// and may be used with trackio.NewPointDecoder.
+type Reader struct {
+	s          *Store
+	start, end time.Time
+
+	started bool
+	parts   []time.Time // partitions not yet read
+	trk     track.Track // points of the current partition not yet read
+}
+
+// Reader returns a Reader for the points of s having time t
+// where start <= t and t < end, as with Range.
+func (s *Store) Reader(start, end time.Time) *Reader {
+	return &Reader{s: s, start: start, end: end}
+}
+
+// ReadPoint returns the next point from the store.
+// It returns io.EOF after the last point.
+func (r *Reader) ReadPoint() (trackio.Point, error) {
+	if !r.started {
+		parts, err := r.s.parts(r.start, r.end)
+		if err != nil {
+			return trackio.Point{}, err
+		}
+		r.parts, r.started = parts, true
+	}
+
+	for len(r.trk) == 0 {
+		if len(r.parts) == 0 {
+			return trackio.Point{}, io.EOF
+		}
+
+		trk, err := readPart(r.s.path(r.parts[0]))
+		if err != nil {
+			return trackio.Point{}, err
+		}
+		r.parts = r.parts[1:]
+		r.trk = inRange(trk, r.start, r.end)
+	}
+
+	p := r.trk[0]
+	r.trk = r.trk[1:]
+	return trackio.FromCompact(p), nil
+}
@@ -0,0 +1,432 @@
+// Package trackstore implements an on-disk location history store.
+//
+// Track points are kept in files of the compact binary format
+// of track.Track, partitioned by UTC day or month:
+//
+//	dir/trakstore           partition setting
+//	dir/2018/2018-01-02.trak day partition file
+//	dir/2018/2018-01.trak    month partition file
+//
+// Time range queries read only the partition files
+// overlapping the range.
+//
+// Importing this package registers store directories
+// as a directory format in package trackio,
+// so that trackio.OpenFile recognises them.
+// Use RegisterDir to read only a time range of stores.
+package trackstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tajtiattila/track"
+	"github.com/tajtiattila/track/trackio"
+)
+
+func init() {
+	RegisterDir(trackio.DefaultRegistry, time.Time{}, time.Time{})
+}
+
+// dirFormat is the name of the directory format of stores.
+const dirFormat = "trakstore"
+
+// RegisterDir registers store directories as a directory format in r,
+// replacing the store directory format already registered, if any.
+//
+// Decoders of stores opened using r read the points of the store
+// between start and end, as with Store.Reader.
+func RegisterDir(r *trackio.Registry, start, end time.Time) {
+	r.SetDir(dirFormat, func(dir string) (*trackio.Decoder, bool, error) {
+		if !IsStore(dir) {
+			return nil, false, nil
+		}
+
+		s, err := Open(dir)
+		if err != nil {
+			return nil, false, err
+		}
+
+		d := trackio.NewPointDecoder(s.Reader(start, end))
+		// points were filtered on ingest
+		d.Accuracy = trackio.NoAccuracy
+		return d, true, nil
+	})
+}
+
+// Partition specifies the time span of store files.
+type Partition int
+
+const (
+	Day   Partition = iota // one file per UTC day
+	Month                  // one file per UTC month
+)
+
+func (p Partition) String() string {
+	switch p {
+	case Day:
+		return "day"
+	case Month:
+		return "month"
+	}
+	return "invalid"
+}
+
+// ParsePartition returns the partition for s,
+// which is either "day" or "month".
+func ParsePartition(s string) (Partition, error) {
+	for _, p := range []Partition{Day, Month} {
+		if s == p.String() {
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("trackstore: invalid partition %q", s)
+}
+
+// layout returns the file name time layout for p.
+func (p Partition) layout() string {
+	if p == Month {
+		return "2006-01"
+	}
+	return "2006-01-02"
+}
+
+// start returns the start of the partition of t.
+func (p Partition) start(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	if p == Month {
+		d = 1
+	}
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// end returns the end of the partition starting at t.
+func (p Partition) end(t time.Time) time.Time {
+	if p == Month {
+		return t.AddDate(0, 1, 0)
+	}
+	return t.AddDate(0, 0, 1)
+}
+
+const (
+	confName = "trakstore" // name of the partition setting file
+	fileExt  = ".trak"     // extension of partition files
+)
+
+// ErrExist indicates that a store already exists.
+var ErrExist = errors.New("trackstore: store already exists")
+
+// Store is an on-disk location history store.
+//
+// A Store may be used by multiple goroutines simultaneously,
+// but the store directory must not be modified by other processes.
+type Store struct {
+	dir  string
+	part Partition
+
+	mu sync.Mutex // serializes writes
+}
+
+// IsStore reports whether dir holds a store.
+func IsStore(dir string) bool {
+	fi, err := os.Stat(filepath.Join(dir, confName))
+	return err == nil && fi.Mode().IsRegular()
+}
+
+// Create creates a new store in dir using partition p.
+//
+// The directory dir is created if necessary.
+// Create returns ErrExist if dir already holds a store.
+func Create(dir string, p Partition) (*Store, error) {
+	if p != Day && p != Month {
+		return nil, fmt.Errorf("trackstore: invalid partition %d", int(p))
+	}
+
+	if IsStore(dir) {
+		return nil, ErrExist
+	}
+
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return nil, err
+	}
+
+	conf := filepath.Join(dir, confName)
+	if err := ioutil.WriteFile(conf, []byte(p.String()+"\n"), 0666); err != nil {
+		return nil, err
+	}
+
+	return &Store{dir: dir, part: p}, nil
+}
+
+// Open opens the store in dir.
+func Open(dir string) (*Store, error) {
+	conf, err := ioutil.ReadFile(filepath.Join(dir, confName))
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := ParsePartition(strings.TrimSpace(string(conf)))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Store{dir: dir, part: p}, nil
+}
+
+// Dir returns the directory of s.
+func (s *Store) Dir() string { return s.dir }
+
+// Partition returns the partition of s.
+func (s *Store) Partition() Partition { return s.part }
+
+// Add adds the points of trk to s.
+//
+// Points identical to points already in s are dropped.
+// Add returns the number of points added.
+func (s *Store) Add(trk track.Track) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	parts := make(map[time.Time]track.Track)
+	for _, p := range trk {
+		t := s.part.start(p.Time())
+		parts[t] = append(parts[t], p)
+	}
+
+	// write partitions in order for predictable results on errors
+	keys := make([]time.Time, 0, len(parts))
+	for t := range parts {
+		keys = append(keys, t)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Before(keys[j]) })
+
+	added := 0
+	for _, t := range keys {
+		n, err := s.addPart(t, parts[t])
+		added += n
+		if err != nil {
+			return added, err
+		}
+	}
+	return added, nil
+}
+
+func (s *Store) addPart(t time.Time, seg track.Track) (int, error) {
+	fn := s.path(t)
+
+	trk, err := readPart(fn)
+	if err != nil && !os.IsNotExist(err) {
+		return 0, err
+	}
+
+	n0 := len(trk)
+	trk = dedupe(append(trk, seg...))
+	if len(trk) == n0 {
+		return 0, nil
+	}
+
+	return len(trk) - n0, writePart(fn, trk)
+}
+
+// ingestBatch is the number of points
+// buffered by Ingest before adding them to the store.
+const ingestBatch = 1 << 16
+
+// Ingest adds the track decoded by d to s.
+//
+// Points are added in batches as they are decoded.
+// Until the first point having an accuracy value is decoded,
+// points are buffered in memory, because d may still reject them.
+//
+// It returns the number of points added. If an error occurs,
+// the points of earlier batches remain in s.
+func (s *Store) Ingest(ctx context.Context, d *trackio.Decoder) (int, error) {
+	// final is set once d can't reject earlier points
+	final := d.Accuracy >= trackio.NoAccuracy
+
+	var buf track.Track
+	added := 0
+	err := d.Points(ctx, func(p trackio.Point, reset bool) error {
+		if reset {
+			buf = buf[:0]
+		}
+		if p.Acc < trackio.NoAccuracy {
+			final = true
+		}
+
+		buf = append(buf, p.Compact())
+		if !final || len(buf) < ingestBatch {
+			return nil
+		}
+
+		n, err := s.Add(buf)
+		added += n
+		buf = buf[:0]
+		return err
+	})
+	if err != nil {
+		return added, err
+	}
+
+	n, err := s.Add(buf)
+	return added + n, err
+}
+
+// Range returns the points of s having time t where
+// start <= t and t < end, in chronological order.
+//
+// If start or end is the zero time,
+// the range is unbounded in that direction.
+func (s *Store) Range(start, end time.Time) (track.Track, error) {
+	parts, err := s.parts(start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	var trk track.Track
+	for _, t := range parts {
+		seg, err := readPart(s.path(t))
+		if err != nil {
+			return nil, err
+		}
+		trk = append(trk, inRange(seg, start, end)...)
+	}
+	return trk, nil
+}
+
+// path returns the path of the partition file starting at t.
+func (s *Store) path(t time.Time) string {
+	return filepath.Join(s.dir, t.Format("2006"), t.Format(s.part.layout())+fileExt)
+}
+
+// parts returns the start times of the partitions in s
+// overlapping the range from start to end, in chronological order.
+func (s *Store) parts(start, end time.Time) ([]time.Time, error) {
+	files, err := filepath.Glob(filepath.Join(s.dir, "[0-9]*", "*"+fileExt))
+	if err != nil {
+		return nil, err
+	}
+
+	var v []time.Time
+	for _, fn := range files {
+		name := strings.TrimSuffix(filepath.Base(fn), fileExt)
+		t, err := time.Parse(s.part.layout(), name)
+		if err != nil || s.path(t) != fn {
+			// not a partition file
+			continue
+		}
+
+		if !start.IsZero() && !s.part.end(t).After(start) {
+			continue
+		}
+		if !end.IsZero() && !t.Before(end) {
+			continue
+		}
+		v = append(v, t)
+	}
+
+	sort.Slice(v, func(i, j int) bool { return v[i].Before(v[j]) })
+	return v, nil
+}
+
+// inRange returns the points of the chronologically
+// ordered trk within the range from start to end.
+func inRange(trk track.Track, start, end time.Time) track.Track {
+	i, j := 0, len(trk)
+	if !start.IsZero() {
+		i = sort.Search(len(trk), func(k int) bool {
+			return !trk[k].Time().Before(start)
+		})
+	}
+	if !end.IsZero() {
+		j = sort.Search(len(trk), func(k int) bool {
+			return !trk[k].Time().Before(end)
+		})
+	}
+	if j < i {
+		j = i
+	}
+	return trk[i:j]
+}
+
+// dedupe sorts trk chronologically,
+// and removes the points identical to other points.
+func dedupe(trk track.Track) track.Track {
+	sort.SliceStable(trk, func(i, j int) bool {
+		return trk[i].Time().Before(trk[j].Time())
+	})
+
+	v := trk[:0]
+	run := 0 // start of points in v having the same time
+	for _, p := range trk {
+		if len(v) != 0 && !v[len(v)-1].Time().Equal(p.Time()) {
+			run = len(v)
+		}
+
+		dup := false
+		for _, q := range v[run:] {
+			if p == q {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			v = append(v, p)
+		}
+	}
+	return v
+}
+
+func readPart(fn string) (track.Track, error) {
+	data, err := ioutil.ReadFile(fn)
+	if err != nil {
+		return nil, err
+	}
+
+	var trk track.Track
+	if err := trk.UnmarshalBinary(data); err != nil {
+		return nil, fmt.Errorf("trackstore: %s: %v", fn, err)
+	}
+	return trk, nil
+}
+
+// writePart writes trk into the partition file fn.
+//
+// The data is written to a temporary file first,
+// so that fn is never left incomplete.
+func writePart(fn string, trk track.Track) error {
+	data, err := trk.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(fn)
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(dir, ".tmp-")
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), fn)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
@@ -0,0 +1,177 @@
+package trackstore_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tajtiattila/track"
+	"github.com/tajtiattila/track/trackio"
+	"github.com/tajtiattila/track/trackstore"
+)
+
+// hours returns a track having a point every hour
+// from epoch for n hours, at latitude lat.
+func hours(epoch time.Time, n int, lat float64) track.Track {
+	var trk track.Track
+	for i := 0; i < n; i++ {
+		trk = append(trk, track.Pt(epoch.Add(time.Duration(i)*time.Hour), lat, 19).WithAcc(5))
+	}
+	return trk
+}
+
+func TestStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "store")
+	epoch := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	s, err := trackstore.Create(dir, trackstore.Day)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := trackstore.Create(dir, trackstore.Month); err != trackstore.ErrExist {
+		t.Fatalf("got error %v creating existing store, want ErrExist", err)
+	}
+
+	// three days
+	n, err := s.Add(hours(epoch, 72, 47))
+	if err != nil || n != 72 {
+		t.Fatalf("added %d points, error %v", n, err)
+	}
+
+	// overlapping track with duplicates, and a different point
+	// at the time of an existing one
+	trk := hours(epoch.Add(60*time.Hour), 24, 47)
+	trk = append(trk, track.Pt(epoch.Add(70*time.Hour), 48, 19))
+	n, err = s.Add(trk)
+	if err != nil || n != 12+1 {
+		t.Fatalf("added %d points, want 13, error %v", n, err)
+	}
+
+	for _, name := range []string{"2018-01-01", "2018-01-04"} {
+		if _, err := os.Stat(filepath.Join(dir, "2018", name+".trak")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !trackstore.IsStore(dir) {
+		t.Fatal("store not detected")
+	}
+	s, err = trackstore.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Partition() != trackstore.Day {
+		t.Fatalf("got partition %v, want day", s.Partition())
+	}
+
+	all, err := s.Range(time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 85 {
+		t.Fatalf("got %d points, want 85", len(all))
+	}
+	for i := 1; i < len(all); i++ {
+		if all[i].Time().Before(all[i-1].Time()) {
+			t.Fatalf("point %d out of order", i)
+		}
+	}
+
+	start, end := epoch.Add(23*time.Hour), epoch.Add(49*time.Hour)
+	got, err := s.Range(start, end)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 26 || !got[0].Time().Equal(start) {
+		t.Fatalf("got %d points from %v, want 26 from %v", len(got), got[0].Time(), start)
+	}
+
+	// store as decoder input
+	d := trackio.NewPointDecoder(s.Reader(start, end))
+	dt, err := d.Track()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dt) != len(got) {
+		t.Fatalf("decoded %d points, want %d", len(dt), len(got))
+	}
+	for i := range dt {
+		if dt[i].Compact() != got[i] {
+			t.Fatalf("point %d: got %+v, want %+v", i, dt[i].Compact(), got[i])
+		}
+	}
+}
+
+func TestStoreIngest(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := trackstore.Create(dir, trackstore.Month)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the first point is rejected once a point
+	// having an accuracy value is decoded
+	const src = `time,lat,lon,acc
+2018-01-31T23:58:00Z,47.4,19.05,
+2018-01-31T23:59:00Z,47.5,19.05,10
+2018-02-01T00:01:00Z,47.6,19.05,10
+`
+	for i, want := range []int{2, 0} {
+		d := trackio.NewDecoder(strings.NewReader(src))
+		n, err := s.Ingest(context.Background(), d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != want {
+			t.Fatalf("ingest #%d: added %d points, want %d", i, n, want)
+		}
+	}
+
+	for _, name := range []string{"2018-01", "2018-02"} {
+		if _, err := os.Stat(filepath.Join(dir, "2018", name+".trak")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	trk, err := s.Range(time.Date(2018, 2, 1, 0, 0, 0, 0, time.UTC), time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trk) != 1 || trk[0].Lat() != 47.6 {
+		t.Fatalf("got track %v", trk)
+	}
+
+	// store directory as decoder input
+	d, closer, err := trackio.OpenFile(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closer.Close()
+	all, err := d.Track()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 || all[0].Lat != 47.5 {
+		t.Fatalf("got track %v from OpenFile", all)
+	}
+
+	// time range of store directories
+	r := trackio.DefaultRegistry.Clone()
+	trackstore.RegisterDir(r, time.Date(2018, 2, 1, 0, 0, 0, 0, time.UTC), time.Time{})
+	d, closer, err = r.OpenFile(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closer.Close()
+	part, err := d.Track()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(part) != 1 || part[0].Lat != 47.6 {
+		t.Fatalf("got track %v from range", part)
+	}
+}
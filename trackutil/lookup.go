@@ -52,3 +52,34 @@ func Lookup(trk Track, t time.Time) (lat, long float64) {
 
 	return lat, long
 }
+
+// SegmentTrack is a Track having segment boundaries.
+type SegmentTrack interface {
+	Track
+
+	// SegmentStart reports whether the point at index i
+	// starts a new segment.
+	SegmentStart(i int) bool
+}
+
+// LookupSegment is like Lookup, but it returns ok == false
+// if t is before the first or after the last point of trk,
+// or if t falls between two segments of trk.
+func LookupSegment(trk SegmentTrack, t time.Time) (lat, long float64, ok bool) {
+	i := trk.TimeIndex(t)
+	if i == 0 {
+		return 0, 0, false
+	}
+
+	pt, plat, plong := trk.Pt(i - 1)
+	if pt.Equal(t) {
+		return plat, plong, true
+	}
+
+	if i == trk.Len() || trk.SegmentStart(i) {
+		return 0, 0, false
+	}
+
+	lat, long = Lookup(trk, t)
+	return lat, long, true
+}